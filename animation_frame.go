@@ -0,0 +1,58 @@
+package avatarsdkgo
+
+import (
+	"time"
+
+	message "github.com/spatialwalk/avatar-sdk-go/proto/generated"
+)
+
+// AnimationFrame is a decoded ServerResponseAnimation message.
+type AnimationFrame struct {
+	// ConnectionID is the connection_id reported by the server.
+	ConnectionID string
+	// ReqID is the request the frame belongs to.
+	ReqID string
+	// End is true for the final frame of the request.
+	End bool
+	// Payload is the serialized message.Message envelope, identical to the TransportFrames payload.
+	Payload []byte
+	// ReceivedAt is when the SDK read the frame from the connection.
+	ReceivedAt time.Time
+	// Seq is the zero-based position of the frame within its request.
+	Seq uint64
+}
+
+// frameSequencer numbers animation frames per request ID for a single connection.
+// It is only used from the connection's read loop.
+type frameSequencer struct {
+	next map[string]uint64
+}
+
+func newFrameSequencer() *frameSequencer {
+	return &frameSequencer{next: make(map[string]uint64)}
+}
+
+// frame builds the AnimationFrame for anim and advances the request's sequence.
+func (q *frameSequencer) frame(anim *message.ServerResponseAnimation, payload []byte, receivedAt time.Time) AnimationFrame {
+	reqID := anim.GetReqId()
+	seq := q.next[reqID]
+	if anim.GetEnd() {
+		delete(q.next, reqID)
+	} else {
+		q.next[reqID] = seq + 1
+	}
+
+	return AnimationFrame{
+		ConnectionID: anim.GetConnectionId(),
+		ReqID:        reqID,
+		End:          anim.GetEnd(),
+		Payload:      append([]byte(nil), payload...),
+		ReceivedAt:   receivedAt,
+		Seq:          seq,
+	}
+}
+
+// forget drops the sequence state for a request that ended without a final frame.
+func (q *frameSequencer) forget(reqID string) {
+	delete(q.next, reqID)
+}
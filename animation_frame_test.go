@@ -0,0 +1,104 @@
+package avatarsdkgo
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	message "github.com/spatialwalk/avatar-sdk-go/proto/generated"
+)
+
+func TestFrameSequencerNumbersFramesPerRequest(t *testing.T) {
+	sequencer := newFrameSequencer()
+	receivedAt := time.Unix(1754824283, 0)
+
+	steps := []struct {
+		reqID string
+		end   bool
+		want  uint64
+	}{
+		{"req-a", false, 0},
+		{"req-b", false, 0},
+		{"req-a", false, 1},
+		{"req-a", true, 2},
+		{"req-b", true, 1},
+		{"req-a", false, 0},
+	}
+
+	for i, step := range steps {
+		anim := &message.ServerResponseAnimation{ConnectionId: "conn-1", ReqId: step.reqID, End: step.end}
+		frame := sequencer.frame(anim, []byte("payload"), receivedAt)
+		if frame.Seq != step.want {
+			t.Fatalf("step %d: expected seq %d for %q, got %d", i, step.want, step.reqID, frame.Seq)
+		}
+		if frame.ReqID != step.reqID || frame.End != step.end || frame.ConnectionID != "conn-1" {
+			t.Fatalf("step %d: unexpected frame fields %+v", i, frame)
+		}
+		if !frame.ReceivedAt.Equal(receivedAt) {
+			t.Fatalf("step %d: expected ReceivedAt %v, got %v", i, receivedAt, frame.ReceivedAt)
+		}
+	}
+}
+
+func TestFrameSequencerCopiesPayload(t *testing.T) {
+	sequencer := newFrameSequencer()
+	payload := []byte("payload")
+
+	frame := sequencer.frame(&message.ServerResponseAnimation{ReqId: "req-a"}, payload, time.Now())
+	payload[0] = 'X'
+
+	if string(frame.Payload) != "payload" {
+		t.Fatalf("expected frame payload to be copied, got %q", frame.Payload)
+	}
+}
+
+func TestOnAnimationFrameReceivesTypedFrames(t *testing.T) {
+	receivedAt := time.Date(2025, time.October, 27, 14, 30, 34, 0, time.UTC)
+	frames := make(chan AnimationFrame, 3)
+	rawFrames := make(chan []byte, 3)
+
+	ingress := newFakeIngress(t)
+	session := NewAvatarSession(
+		WithAvatarID("avatar-123"),
+		WithAppID("app-123"),
+		WithIngressEndpointURL(ingress.url()),
+		WithTransportFrames(func(data []byte, last bool) { rawFrames <- data }),
+		WithOnAnimationFrame(func(frame AnimationFrame) { frames <- frame }),
+	)
+	session.sessionToken = "session-token-123"
+	session.now = func() time.Time { return receivedAt }
+	if _, err := session.Start(context.Background()); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	defer session.Close() // nolint:errcheck
+	serverConn := ingress.accept(t)
+
+	serverConn.send(t, animationMessage(serverConn.connectionID, "req-1", false))
+	serverConn.send(t, animationMessage(serverConn.connectionID, "req-1", false))
+	serverConn.send(t, animationMessage(serverConn.connectionID, "req-1", true))
+
+	for i := 0; i < 3; i++ {
+		select {
+		case frame := <-frames:
+			raw := <-rawFrames
+			if frame.Seq != uint64(i) {
+				t.Fatalf("expected seq %d, got %d", i, frame.Seq)
+			}
+			if frame.ReqID != "req-1" || frame.ConnectionID != serverConn.connectionID {
+				t.Fatalf("unexpected frame identity %+v", frame)
+			}
+			if frame.End != (i == 2) {
+				t.Fatalf("expected end=%v for frame %d", i == 2, i)
+			}
+			if !frame.ReceivedAt.Equal(receivedAt) {
+				t.Fatalf("expected ReceivedAt %v, got %v", receivedAt, frame.ReceivedAt)
+			}
+			if !bytes.Equal(frame.Payload, raw) {
+				t.Fatal("expected typed frame payload to match TransportFrames payload")
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for frame %d", i)
+		}
+	}
+}
@@ -3,7 +3,7 @@ package avatarsdkgo
 import (
 	"encoding/binary"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/rand/v2"
 	"strings"
 
@@ -78,6 +78,11 @@ type OggOpusStreamEncoder struct {
 
 // NewOggOpusStreamEncoder creates an encoder for PCM to Ogg Opus conversion.
 func NewOggOpusStreamEncoder(sampleRate int, bitrate int, config *OggOpusEncoderConfig, collectEncodedOutput bool) (*OggOpusStreamEncoder, error) {
+	return newOggOpusStreamEncoder(sampleRate, bitrate, config, collectEncodedOutput, slog.Default())
+}
+
+// newOggOpusStreamEncoder is NewOggOpusStreamEncoder with the logger used for non-fatal setup problems.
+func newOggOpusStreamEncoder(sampleRate int, bitrate int, config *OggOpusEncoderConfig, collectEncodedOutput bool, logger *slog.Logger) (*OggOpusStreamEncoder, error) {
 	resolved := resolveOggOpusEncoderConfig(config)
 	if err := validateOggOpusEncoderConfig(sampleRate, resolved.FrameDurationMS, resolved.Application); err != nil {
 		return nil, err
@@ -95,7 +100,7 @@ func NewOggOpusStreamEncoder(sampleRate int, bitrate int, config *OggOpusEncoder
 
 	if bitrate > 0 {
 		if err := encoder.SetBitrate(bitrate); err != nil {
-			log.Printf("avatarsdkgo: failed to set Opus encoder bitrate %d, using encoder default: %v", bitrate, err)
+			logger.Warn("failed to set Opus encoder bitrate, using encoder default", "bitrate", bitrate, "error", err)
 		}
 	}
 
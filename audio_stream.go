@@ -0,0 +1,125 @@
+package avatarsdkgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// defaultStreamChunkDuration is the audio length per message when StreamAudioOptions.ChunkDuration is unset.
+const defaultStreamChunkDuration = 20 * time.Millisecond
+
+// pcmBytesPerSample is the frame size of mono 16-bit little-endian PCM.
+const pcmBytesPerSample = 2
+
+// StreamAudioOptions configures StreamAudio.
+type StreamAudioOptions struct {
+	// ChunkDuration is the length of audio sent in each message. Defaults to 20ms.
+	ChunkDuration time.Duration
+	// Speedup paces chunks relative to wall-clock time: 1 sends audio in real time, 2 twice
+	// as fast, and so on. Zero (default) sends chunks as fast as the connection accepts them.
+	Speedup float64
+	// ReqID is the request ID to use. Empty (default) generates one with GenerateLogID.
+	ReqID string
+}
+
+// chunkSize returns the number of PCM bytes per chunk at sampleRate.
+func (o StreamAudioOptions) chunkSize(sampleRate int) int {
+	samples := int(int64(sampleRate) * int64(o.ChunkDuration) / int64(time.Second))
+	return max(samples, 1) * pcmBytesPerSample
+}
+
+// StreamAudio reads mono 16-bit PCM from r and sends it as a new request in chunks of
+// opts.ChunkDuration, computed from SessionConfig.SampleRate. When opts.Speedup is set the
+// chunks are paced to reproduce a live source such as a microphone or a TTS stream. Once r
+// returns io.EOF the request is terminated with end=true and its ID is returned.
+//
+// StreamAudio blocks until r is exhausted, ctx is done, or a send fails. On failure the
+// request finishes with the error, which is returned together with the request ID, and is
+// interrupted instead of ended so the server stops working on it. Frames the server still
+// sends for it are filtered as after InterruptRequest. The internal Ogg Opus encoder is used when enabled; pre-encoded Ogg Opus input cannot be
+// streamed because chunk boundaries are derived from the PCM sample rate.
+func (s *AvatarSession) StreamAudio(ctx context.Context, r io.Reader, opts StreamAudioOptions) (string, error) {
+	if s == nil {
+		return "", errors.New("stream audio: session is nil")
+	}
+	if r == nil {
+		return "", errors.New("stream audio: reader is nil")
+	}
+	if s.config.AudioFormat == AudioFormatOggOpus && !s.usesInternalOggOpusEncoder() {
+		return "", errors.New("stream audio: requires PCM input")
+	}
+	if s.config.SampleRate <= 0 {
+		return "", fmt.Errorf("stream audio: invalid sample rate %d", s.config.SampleRate)
+	}
+	if opts.ChunkDuration <= 0 {
+		opts.ChunkDuration = defaultStreamChunkDuration
+	}
+	if opts.Speedup < 0 {
+		return "", fmt.Errorf("stream audio: invalid speedup %v", opts.Speedup)
+	}
+
+	req, err := s.startRequest("stream audio", []RequestOption{WithRequestID(opts.ReqID)})
+	if err != nil {
+		return "", err
+	}
+	if err := s.streamRequestAudio(ctx, req, r, opts); err != nil {
+		s.abandonRequest(ctx, req, err)
+		return req.id, err
+	}
+	return req.id, nil
+}
+
+// abandonRequest finishes req with err and interrupts it. The interrupt is sent even when ctx
+// is already done, bounded by closeMessageTimeout.
+func (s *AvatarSession) abandonRequest(ctx context.Context, req *Request, err error) {
+	req.finish(err)
+
+	interruptCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), closeMessageTimeout)
+	defer cancel()
+	if _, interruptErr := s.interrupt(interruptCtx, "stream audio", req.id); interruptErr != nil {
+		s.currentConnLogger().Debug("abandoned request not interrupted", "req_id", req.id, "error", interruptErr)
+	}
+
+	s.mu.Lock()
+	if s.requests[req.id] == req {
+		delete(s.requests, req.id)
+	}
+	if s.current == req {
+		s.current = nil
+	}
+	s.mu.Unlock()
+}
+
+// streamRequestAudio sends r to req chunk by chunk and ends the request at EOF.
+func (s *AvatarSession) streamRequestAudio(ctx context.Context, req *Request, r io.Reader, opts StreamAudioOptions) error {
+	chunk := make([]byte, opts.chunkSize(s.config.SampleRate))
+	start := time.Now()
+	var sent time.Duration
+
+	for {
+		n, readErr := io.ReadFull(r, chunk)
+		if readErr != nil && !errors.Is(readErr, io.EOF) && !errors.Is(readErr, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("stream audio: read: %w", readErr)
+		}
+		if n > 0 {
+			if opts.Speedup > 0 {
+				target := start.Add(time.Duration(float64(sent) / opts.Speedup))
+				if !sleepContext(ctx, time.Until(target)) {
+					return fmt.Errorf("stream audio: %w", ctx.Err())
+				}
+			}
+			if err := s.sendRequestAudio(ctx, req, chunk[:n], false); err != nil {
+				return err
+			}
+			sent += time.Duration(n/pcmBytesPerSample) * time.Second / time.Duration(s.config.SampleRate)
+		}
+		if readErr != nil {
+			break
+		}
+	}
+
+	return s.sendRequestAudio(ctx, req, nil, true)
+}
@@ -0,0 +1,158 @@
+package avatarsdkgo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamAudioChunksByDuration(t *testing.T) {
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress, WithSampleRate(16000))
+	defer session.Close() // nolint:errcheck
+	serverConn := ingress.accept(t)
+
+	// 50ms of audio at 16kHz is sent as two 20ms chunks, a 10ms remainder and end=true.
+	audio := bytes.Repeat([]byte{0x01, 0x02}, 800)
+	reqID, err := session.StreamAudio(context.Background(), bytes.NewReader(audio), StreamAudioOptions{})
+	if err != nil {
+		t.Fatalf("StreamAudio returned error: %v", err)
+	}
+
+	for _, want := range []struct {
+		size int
+		end  bool
+	}{{640, false}, {640, false}, {320, false}, {0, true}} {
+		input := serverConn.next(t).GetClientAudioInput()
+		if input.GetReqId() != reqID {
+			t.Fatalf("expected audio for %q, got %q", reqID, input.GetReqId())
+		}
+		if len(input.GetAudio()) != want.size || input.GetEnd() != want.end {
+			t.Fatalf("expected %d bytes end=%v, got %d bytes end=%v", want.size, want.end, len(input.GetAudio()), input.GetEnd())
+		}
+	}
+}
+
+func TestStreamAudioPacesChunks(t *testing.T) {
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress, WithSampleRate(16000))
+	defer session.Close() // nolint:errcheck
+	ingress.accept(t)
+
+	// Ten 10ms chunks: the last one is due 90ms after the first in real time, 45ms at 2x.
+	audio := make([]byte, 3200)
+	for _, tc := range []struct {
+		speedup float64
+		minimum time.Duration
+	}{{1, 90 * time.Millisecond}, {2, 45 * time.Millisecond}} {
+		start := time.Now()
+		opts := StreamAudioOptions{ChunkDuration: 10 * time.Millisecond, Speedup: tc.speedup}
+		if _, err := session.StreamAudio(context.Background(), bytes.NewReader(audio), opts); err != nil {
+			t.Fatalf("StreamAudio returned error: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < tc.minimum {
+			t.Fatalf("speedup %v: expected at least %v, took %v", tc.speedup, tc.minimum, elapsed)
+		}
+	}
+}
+
+func TestStreamAudioStopsOnContext(t *testing.T) {
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress, WithSampleRate(16000))
+	defer session.Close() // nolint:errcheck
+	serverConn := ingress.accept(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	opts := StreamAudioOptions{ChunkDuration: time.Second, Speedup: 1}
+	reqID, err := session.StreamAudio(ctx, bytes.NewReader(make([]byte, 64000)), opts)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	input := serverConn.next(t).GetClientAudioInput()
+	if input.GetReqId() != reqID || input.GetEnd() {
+		t.Fatalf("expected the first chunk of %q without end, got %q end=%v", reqID, input.GetReqId(), input.GetEnd())
+	}
+	if req := session.lookupRequest(reqID); req != nil {
+		t.Fatalf("expected abandoned request %q to be removed", reqID)
+	}
+}
+
+func TestStreamAudioInterruptsAbandonedRequest(t *testing.T) {
+	frames := make(chan string, 4)
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress,
+		WithSampleRate(16000),
+		WithInterruptQuietPeriod(time.Minute),
+		WithTransportFrames(func([]byte, bool) { frames <- "transport" }),
+		WithOnAnimationFrame(func(frame AnimationFrame) { frames <- frame.ReqID }),
+	)
+	defer session.Close() // nolint:errcheck
+	serverConn := ingress.accept(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	opts := StreamAudioOptions{ChunkDuration: time.Second, Speedup: 1}
+	done := make(chan string, 1)
+	go func() {
+		reqID, _ := session.StreamAudio(ctx, bytes.NewReader(make([]byte, 64000)), opts)
+		done <- reqID
+	}()
+	reqID := serverConn.next(t).GetClientAudioInput().GetReqId()
+	cancel()
+	<-done
+
+	if got := serverConn.next(t).GetClientInterrupt().GetReqId(); got != reqID {
+		t.Fatalf("expected an interrupt for %q, got %q", reqID, got)
+	}
+
+	// Frames the server still sends for the abandoned request never reach callbacks.
+	serverConn.send(t, animationMessage(serverConn.connectionID, reqID, false))
+	serverConn.send(t, animationMessage(serverConn.connectionID, "req-other", true))
+	for _, want := range []string{"transport", "req-other"} {
+		select {
+		case got := <-frames:
+			if got != want {
+				t.Fatalf("expected %q, got %q", want, got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for %q", want)
+		}
+	}
+	if req := session.lookupRequest(reqID); req != nil {
+		t.Fatalf("expected abandoned request %q to be removed", reqID)
+	}
+}
+
+func TestStreamAudioReadError(t *testing.T) {
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress)
+	defer session.Close() // nolint:errcheck
+	ingress.accept(t)
+
+	readErr := errors.New("decoder failed")
+	r := io.MultiReader(bytes.NewReader(make([]byte, 100)), &failingReader{err: readErr})
+	if _, err := session.StreamAudio(context.Background(), r, StreamAudioOptions{}); !errors.Is(err, readErr) {
+		t.Fatalf("expected read error, got %v", err)
+	}
+}
+
+func TestStreamAudioRejectsEncodedInput(t *testing.T) {
+	session := NewAvatarSession(WithAudioFormat(AudioFormatOggOpus))
+	_, err := session.StreamAudio(context.Background(), bytes.NewReader(nil), StreamAudioOptions{})
+	if err == nil || !strings.Contains(err.Error(), "requires PCM input") {
+		t.Fatalf("expected PCM input error, got %v", err)
+	}
+}
+
+type failingReader struct {
+	err error
+}
+
+func (r *failingReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
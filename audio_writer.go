@@ -0,0 +1,72 @@
+package avatarsdkgo
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrAudioWriterClosed is returned by AudioWriter.Write after Close.
+var ErrAudioWriterClosed = errors.New("audio writer closed")
+
+// AudioWriter is an io.WriteCloser that streams the audio of a single request.
+// Each Write sends one chunk through the same path as SendAudio, including the internal
+// Ogg Opus encoder when it is enabled, and Close sends the terminating end=true message.
+// Frames for the request carry the ID returned by ID.
+//
+// An AudioWriter owns its own request, so it can be used alongside SendAudio and other
+// writers on the same session. It is safe for concurrent use, although concurrent writes
+// interleave their chunks in an unspecified order.
+type AudioWriter struct {
+	req *Request
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewAudioWriter starts a new request on the active connection and returns a writer for its audio.
+// Request options and limits are the same as for BeginRequest.
+func (s *AvatarSession) NewAudioWriter(opts ...RequestOption) (*AudioWriter, error) {
+	req, err := s.startRequest("new audio writer", opts)
+	if err != nil {
+		return nil, err
+	}
+	return &AudioWriter{req: req}, nil
+}
+
+// ID returns the request ID sent to the server as req_id.
+func (w *AudioWriter) ID() string {
+	return w.req.id
+}
+
+// Write sends p as one audio chunk. Empty writes send nothing.
+func (w *AudioWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	closed := w.closed
+	w.mu.Unlock()
+	if closed {
+		return 0, ErrAudioWriterClosed
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if err := w.req.Write(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close sends the terminating end=true message. If that fails the request is finished with
+// the error and Close keeps reporting it; after a successful Close, calling it again has no effect.
+func (w *AudioWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	if err := w.req.End(); err != nil {
+		return err
+	}
+	w.closed = true
+	return nil
+}
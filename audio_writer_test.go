@@ -0,0 +1,134 @@
+package avatarsdkgo
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestAudioWriterStreamsRequest(t *testing.T) {
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress)
+	defer session.Close() // nolint:errcheck
+	serverConn := ingress.accept(t)
+
+	writer, err := session.NewAudioWriter()
+	if err != nil {
+		t.Fatalf("NewAudioWriter returned error: %v", err)
+	}
+	var _ io.WriteCloser = writer
+
+	audio := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a}
+	// Hide bytes.Reader's WriterTo so io.CopyBuffer issues one Write per 4-byte chunk.
+	n, err := io.CopyBuffer(writer, struct{ io.Reader }{bytes.NewReader(audio)}, make([]byte, 4))
+	if err != nil {
+		t.Fatalf("io.CopyBuffer returned error: %v", err)
+	}
+	if n != int64(len(audio)) {
+		t.Fatalf("expected %d bytes copied, got %d", len(audio), n)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	var received []byte
+	for _, want := range []bool{false, false, false, true} {
+		input := serverConn.next(t).GetClientAudioInput()
+		if input.GetReqId() != writer.ID() {
+			t.Fatalf("expected audio for %q, got %q", writer.ID(), input.GetReqId())
+		}
+		if input.GetEnd() != want {
+			t.Fatalf("expected end=%v, got %v", want, input.GetEnd())
+		}
+		received = append(received, input.GetAudio()...)
+	}
+	if !bytes.Equal(received, audio) {
+		t.Fatalf("expected audio %v, got %v", audio, received)
+	}
+
+	if _, err := writer.Write([]byte{0x01}); !errors.Is(err, ErrAudioWriterClosed) {
+		t.Fatalf("expected ErrAudioWriterClosed, got %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("second Close returned error: %v", err)
+	}
+}
+
+func TestAudioWriterIndependentOfSendAudio(t *testing.T) {
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress)
+	defer session.Close() // nolint:errcheck
+	serverConn := ingress.accept(t)
+
+	writer, err := session.NewAudioWriter()
+	if err != nil {
+		t.Fatalf("NewAudioWriter returned error: %v", err)
+	}
+	if _, err := writer.Write([]byte{0x01}); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	reqID, err := session.SendAudio([]byte{0x02}, true)
+	if err != nil {
+		t.Fatalf("SendAudio returned error: %v", err)
+	}
+	if reqID == writer.ID() {
+		t.Fatalf("expected SendAudio to use its own request, got %q", reqID)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	for _, want := range []struct {
+		reqID string
+		end   bool
+	}{{writer.ID(), false}, {reqID, true}, {writer.ID(), true}} {
+		input := serverConn.next(t).GetClientAudioInput()
+		if input.GetReqId() != want.reqID || input.GetEnd() != want.end {
+			t.Fatalf("expected audio input for %q end=%v, got %q end=%v", want.reqID, want.end, input.GetReqId(), input.GetEnd())
+		}
+	}
+}
+
+func TestAudioWriterCloseFailureReleasesRequest(t *testing.T) {
+	clientConn, _ := dialTestWebSocketPair(t)
+	session := NewAvatarSession(
+		WithOutboundQueue(1, OutboundQueueFailFast),
+		WithMaxConcurrentRequests(1),
+	)
+	attachTestConn(session, clientConn)
+	defer session.Close() // nolint:errcheck
+	stallConnWriter(t, session.writer)
+
+	writer, err := session.NewAudioWriter()
+	if err != nil {
+		t.Fatalf("NewAudioWriter returned error: %v", err)
+	}
+	if _, err := writer.Write([]byte{0x01, 0x02}); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	if err := writer.Close(); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull from Close, got %v", err)
+	}
+	if err := writer.Close(); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected a second Close to report the failure again, got %v", err)
+	}
+
+	// The request no longer holds a concurrency slot.
+	session.mu.Lock()
+	inFlight := len(session.requests)
+	session.mu.Unlock()
+	if inFlight != 0 {
+		t.Fatalf("expected the failed request to be released, %d in flight", inFlight)
+	}
+	if _, err := session.NewAudioWriter(); err != nil {
+		t.Fatalf("expected a new request to fit after the failed Close, got %v", err)
+	}
+}
+
+func TestNewAudioWriterNoConnection(t *testing.T) {
+	session := NewAvatarSession()
+	if _, err := session.NewAudioWriter(); !errors.Is(err, ErrSessionNotReady) {
+		t.Fatalf("expected ErrSessionNotReady, got %v", err)
+	}
+}
@@ -7,10 +7,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -21,17 +21,40 @@ import (
 const (
 	sessionTokenPath     = "/session-tokens"
 	ingressWebSocketPath = "/websocket"
+
+	closeMessageTimeout = 5 * time.Second
 )
 
 // AvatarSession represents an active avatar session configured via SessionOptions.
+// All methods are safe for concurrent use.
 type AvatarSession struct {
-	config       *SessionConfig
-	sessionToken string
-	conn         *websocket.Conn
-	currentReqID string
-	lastReqID    string // tracks the most recent request ID for interrupt
-	connectionID string
-	audioEncoder *OggOpusStreamEncoder
+	config *SessionConfig
+
+	// sendLock serializes SendAudio calls so chunks of one request are encoded and queued in order.
+	// It is a one-slot semaphore so callers can stop waiting for it when their context ends.
+	sendLock chan struct{}
+
+	// mu guards the connection and request state below.
+	mu             sync.Mutex
+	state          SessionState
+	sessionToken   string
+	tokenExpiresAt time.Time
+	tokenLifetime  time.Duration // lifetime requested by Init, reused to renew a rejected token
+	modelVersion   string        // model version of the session token minted by Init
+	refresher      *tokenRefresher
+	conn           *websocket.Conn
+	writer         *connWriter
+	current        *Request // request continued by SendAudio until it sends end=true
+	lastReqID      string   // tracks the most recent request ID for interrupt
+	requests       map[string]*Request
+	connectionID   string
+	dispatcher     *dispatcher
+	reconnecting   *reconnectAttempt
+	events         *eventStream // created by the first Events call
+	interrupts     map[string]*Interruption
+
+	stats sessionStats
+	now   func() time.Time
 }
 
 // NewAvatarSession creates a new AvatarSession using the provided SessionOptions.
@@ -42,7 +65,13 @@ func NewAvatarSession(opts ...SessionOption) *AvatarSession {
 			opt(cfg)
 		}
 	}
-	return &AvatarSession{config: cfg}
+	return &AvatarSession{
+		config:   cfg,
+		sendLock: make(chan struct{}, 1),
+		state:    SessionStateIdle,
+		requests: make(map[string]*Request),
+		now:      time.Now,
+	}
 }
 
 // Config returns a copy of the session configuration.
@@ -53,7 +82,9 @@ func (s *AvatarSession) Config() SessionConfig {
 	return *s.config
 }
 
-// Init exchanges configuration credentials for a session token against the console API.
+// Init obtains the session token used by Start. With WithTokenSource or WithSessionToken the
+// token comes from the source; otherwise the configured credentials are exchanged for a
+// token against the console API.
 func (s *AvatarSession) Init(ctx context.Context) error {
 	if s == nil {
 		return errors.New("init avatar session: session is nil")
@@ -62,63 +93,146 @@ func (s *AvatarSession) Init(ctx context.Context) error {
 		return errors.New("init avatar session: session config is nil")
 	}
 
+	s.mu.Lock()
+	if s.state == SessionStateClosed {
+		err := s.stateErrorLocked("init avatar session", ErrSessionClosed)
+		s.mu.Unlock()
+		return err
+	}
+	s.mu.Unlock()
+
 	cfg := s.config
-	if cfg.APIKey == "" {
-		return errors.New("init avatar session: missing API key")
+	var (
+		sessionToken string
+		expiresAt    time.Time
+		lifetime     time.Duration
+		modelVersion string
+		err          error
+	)
+	if cfg.TokenSource != nil {
+		s.logger().Debug("requesting session token from token source")
+		sessionToken, expiresAt, err = s.tokenFromSource(ctx)
+	} else {
+		if cfg.APIKey == "" {
+			return errors.New("init avatar session: missing API key")
+		}
+		if cfg.ConsoleEndpointURL == "" {
+			return errors.New("init avatar session: missing console endpoint URL")
+		}
+		if cfg.ExpireAt.IsZero() {
+			return errors.New("init avatar session: missing expireAt")
+		}
+
+		s.logger().Debug("requesting session token", "expire_at", cfg.ExpireAt)
+		lifetime = cfg.ExpireAt.Sub(s.timeNow())
+		sessionToken, err = s.requestSessionToken(ctx, cfg.ExpireAt)
+		expiresAt = cfg.ExpireAt
+		modelVersion = cfg.ModelVersion
 	}
-	if cfg.ConsoleEndpointURL == "" {
-		return errors.New("init avatar session: missing console endpoint URL")
+	if err != nil {
+		s.logger().Warn("session token request failed", "error", err)
+		return fmt.Errorf("init avatar session: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state == SessionStateClosed {
+		return s.stateErrorLocked("init avatar session", ErrSessionClosed)
+	}
+	s.sessionToken = sessionToken
+	s.tokenExpiresAt = expiresAt
+	s.tokenLifetime = lifetime
+	s.modelVersion = modelVersion
+	if s.state == SessionStateIdle {
+		s.setStateLocked(SessionStateInitialized)
+	}
+	if cfg.TokenRefresh != nil && s.refresher == nil && !expiresAt.IsZero() {
+		s.refresher = s.startTokenRefresh(*cfg.TokenRefresh, expiresAt.Sub(s.timeNow()))
+	} else if s.refresher != nil {
+		s.refresher.wake()
+	}
+	return nil
+}
+
+// TokenExpiresAt returns the expiry of the current session token, or the zero time before Init.
+func (s *AvatarSession) TokenExpiresAt() time.Time {
+	if s == nil {
+		return time.Time{}
 	}
-	if cfg.ExpireAt.IsZero() {
-		return errors.New("init avatar session: missing expireAt")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokenExpiresAt
+}
+
+// ModelVersion returns the model version requested for the current session token, or an
+// empty string before Init, when the console default model is used, or when the token came
+// from a TokenSource.
+func (s *AvatarSession) ModelVersion() string {
+	if s == nil {
+		return ""
 	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.modelVersion
+}
 
-	endpoint := strings.TrimRight(cfg.ConsoleEndpointURL, "/") + sessionTokenPath
+// requestSessionToken mints a session token valid until expireAt against the console API.
+func (s *AvatarSession) requestSessionToken(ctx context.Context, expireAt time.Time) (_ string, err error) {
+	ctx, span := s.startSpan(ctx, SpanTokenExchange)
+	defer func() { endSpan(span, err) }()
+
+	cfg := s.config
+	return mintSessionToken(ctx, s.httpClient(), cfg.ConsoleEndpointURL, cfg.APIKey, cfg.ModelVersion, expireAt)
+}
+
+// mintSessionToken requests a session token valid until expireAt from the console API at consoleURL.
+func mintSessionToken(ctx context.Context, client *http.Client, consoleURL, apiKey, modelVersion string, expireAt time.Time) (string, error) {
+	endpoint := strings.TrimRight(consoleURL, "/") + sessionTokenPath
 
 	payload := sessionTokenRequest{
-		ExpireAt: cfg.ExpireAt.UTC().Unix(),
+		ExpireAt:     expireAt.UTC().Unix(),
+		ModelVersion: modelVersion,
 	}
 
 	body, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("init avatar session: encode request: %w", err)
+		return "", fmt.Errorf("encode request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("init avatar session: create request: %w", err)
+		return "", fmt.Errorf("create request: %w", err)
 	}
-	req.Header.Set("X-Api-Key", cfg.APIKey)
+	req.Header.Set("X-Api-Key", apiKey)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("init avatar session: request session token: %w", err)
+		return "", fmt.Errorf("request session token: %w", err)
 	}
 	defer resp.Body.Close() // nolint:errcheck
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("init avatar session: read response: %w", err)
+		return "", fmt.Errorf("read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("init avatar session: request failed with status %d", resp.StatusCode)
+		return "", fmt.Errorf("request failed with status %d", resp.StatusCode)
 	}
 
 	var tokenResp sessionTokenResponse
 	if err := json.Unmarshal(respBody, &tokenResp); err != nil {
-		return fmt.Errorf("init avatar session: decode response: %w", err)
+		return "", fmt.Errorf("decode response: %w", err)
 	}
 	if len(tokenResp.Errors) > 0 {
-		return fmt.Errorf("init avatar session: %s", formatSessionTokenError(resp.StatusCode, &tokenResp))
+		return "", errors.New(formatSessionTokenError(resp.StatusCode, &tokenResp))
 	}
 	if tokenResp.SessionToken == "" {
-		return errors.New("init avatar session: empty session token in response")
+		return "", errors.New("empty session token in response")
 	}
 
-	s.sessionToken = tokenResp.SessionToken
-	return nil
+	return tokenResp.SessionToken, nil
 }
 
 // Start establishes WebSocket connection to the ingress endpoint and performs v2 handshake.
@@ -130,29 +244,100 @@ func (s *AvatarSession) Start(ctx context.Context) (string, error) {
 	if s.config == nil {
 		return "", errors.New("start avatar session: session config is nil")
 	}
+
+	s.mu.Lock()
+	previous := s.state
+	if err := s.checkStartLocked(); err != nil {
+		s.mu.Unlock()
+		return "", err
+	}
+	if err := validateStartConfig(s.config); err != nil {
+		s.mu.Unlock()
+		return "", err
+	}
+	s.setStateLocked(SessionStateConnecting)
+	sessionToken := s.sessionToken
+	s.mu.Unlock()
+
+	s.logger().Debug("connecting to ingress", "endpoint", s.config.IngressEndpointURL)
+	conn, connectionID, err := s.connect(ctx, sessionToken)
+	if err != nil {
+		s.mu.Lock()
+		if s.state == SessionStateConnecting {
+			s.setStateLocked(previous)
+		}
+		s.mu.Unlock()
+		s.logger().Warn("avatar session start failed", "error", err)
+		return "", err
+	}
+
+	s.mu.Lock()
+	if s.state != SessionStateConnecting {
+		// Close ran while the handshake was in progress.
+		err := s.stateErrorLocked("start avatar session", ErrSessionClosed)
+		s.mu.Unlock()
+		_ = conn.Close()
+		return "", err
+	}
+	s.attachConnLocked(conn, connectionID)
+	s.setStateLocked(SessionStateReady)
+	s.mu.Unlock()
+
+	s.connLogger(connectionID).Debug("avatar session connected")
+	s.recordSessionStarted()
+
+	// Start read loop in background
+	go s.readLoop(ctx, conn)
+
+	return connectionID, nil
+}
+
+// checkStartLocked reports whether Start may be called in the current state.
+// Callers must hold s.mu.
+func (s *AvatarSession) checkStartLocked() error {
+	switch s.state {
+	case SessionStateClosed:
+		return s.stateErrorLocked("start avatar session", ErrSessionClosed)
+	case SessionStateConnecting, SessionStateReady, SessionStateDraining, SessionStateReconnecting:
+		return s.stateErrorLocked("start avatar session", ErrSessionAlreadyStarted)
+	}
 	if s.conn != nil {
-		return "", errors.New("start avatar session: session already started")
+		return s.stateErrorLocked("start avatar session", ErrSessionAlreadyStarted)
 	}
 	if s.sessionToken == "" {
-		return "", errors.New("start avatar session: session not initialized")
+		return s.stateErrorLocked("start avatar session", ErrSessionNotInitialized)
 	}
+	return nil
+}
 
-	cfg := s.config
+// validateStartConfig checks the configuration Start needs to dial the ingress.
+func validateStartConfig(cfg *SessionConfig) error {
 	if cfg.IngressEndpointURL == "" {
-		return "", errors.New("start avatar session: missing ingress endpoint URL")
+		return errors.New("start avatar session: missing ingress endpoint URL")
 	}
 	if cfg.AvatarID == "" {
-		return "", errors.New("start avatar session: missing avatar ID")
+		return errors.New("start avatar session: missing avatar ID")
 	}
 	if cfg.AppID == "" {
-		return "", errors.New("start avatar session: missing app ID")
+		return errors.New("start avatar session: missing app ID")
+	}
+	if cfg.OutboundQueueSize > 0 && cfg.OutboundQueuePolicy == OutboundQueueDropOldestAudio &&
+		cfg.AudioFormat == AudioFormatOggOpus && cfg.OggOpusEncoder != nil {
+		// Dropping a queued Ogg page silently breaks the rest of the request's stream.
+		return errors.New("start avatar session: outbound queue policy drop_oldest_audio cannot be used with the internal Ogg Opus encoder")
 	}
+	return nil
+}
 
+// connect dials the ingress websocket with sessionToken and performs the v2 handshake.
+// The returned connection is not yet shared with other goroutines.
+func (s *AvatarSession) connect(ctx context.Context, sessionToken string) (*websocket.Conn, string, error) {
+	cfg := s.config
 	endpoint := strings.TrimRight(cfg.IngressEndpointURL, "/") + ingressWebSocketPath
 
 	u, err := url.Parse(endpoint)
 	if err != nil {
-		return "", fmt.Errorf("start avatar session: parse ingress endpoint: %w", err)
+		return nil, "", fmt.Errorf("start avatar session: parse ingress endpoint: %w", err)
 	}
 
 	switch strings.ToLower(u.Scheme) {
@@ -163,9 +348,9 @@ func (s *AvatarSession) Start(ctx context.Context) (string, error) {
 	case "ws", "wss":
 		// already websocket scheme
 	case "":
-		return "", errors.New("start avatar session: ingress endpoint scheme missing")
+		return nil, "", errors.New("start avatar session: ingress endpoint scheme missing")
 	default:
-		return "", fmt.Errorf("start avatar session: unsupported scheme %q", u.Scheme)
+		return nil, "", fmt.Errorf("start avatar session: unsupported scheme %q", u.Scheme)
 	}
 
 	q := u.Query()
@@ -175,60 +360,79 @@ func (s *AvatarSession) Start(ctx context.Context) (string, error) {
 	headers := http.Header{}
 	if cfg.UseQueryAuth {
 		q.Set("appId", cfg.AppID)
-		q.Set("sessionKey", s.sessionToken)
+		q.Set("sessionKey", sessionToken)
 	} else {
 		headers.Set("X-App-ID", cfg.AppID)
-		headers.Set("X-Session-Key", s.sessionToken)
+		headers.Set("X-Session-Key", sessionToken)
 	}
 
 	u.RawQuery = q.Encode()
 
-	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, u.String(), headers)
+	dialCtx, dialSpan := s.startSpan(ctx, SpanWebSocketDial)
+	conn, err := s.dialIngress(dialCtx, u.String(), headers)
+	endSpan(dialSpan, err)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// v2 handshake:
+	// 1) client sends ClientConfigureSession
+	// 2) server responds with ServerConfirmSession (connection_id) OR ServerError
+	handshakeCtx, handshakeSpan := s.startSpan(ctx, SpanHandshake)
+	var connectionID string
+	err = s.sendClientConfigureSession(conn)
+	if err == nil {
+		connectionID, err = s.awaitServerConfirmSession(handshakeCtx, conn)
+	}
+	if err == nil {
+		handshakeSpan.SetAttributes(SpanAttribute{Key: AttrConnectionID, Value: connectionID})
+	}
+	endSpan(handshakeSpan, err)
+	if err != nil {
+		_ = conn.Close()
+		return nil, "", err
+	}
+
+	return conn, connectionID, nil
+}
+
+// dialIngress opens the ingress websocket and maps upgrade failures to SDK errors.
+func (s *AvatarSession) dialIngress(ctx context.Context, urlStr string, headers http.Header) (*websocket.Conn, error) {
+	conn, resp, err := s.webSocketDialer().DialContext(ctx, urlStr, headers)
 	if err != nil {
 		if resp != nil {
 			// Map HTTP status to SDK error code
 			if code := mapWSConnectErrorToCode(resp.StatusCode); code != nil {
-				return "", NewAvatarSDKError(*code, fmt.Sprintf("WebSocket auth failed (HTTP %d)", resp.StatusCode))
+				return nil, NewAvatarSDKError(*code, fmt.Sprintf("WebSocket auth failed (HTTP %d)", resp.StatusCode))
 			}
 			if resp.Body != nil {
 				defer resp.Body.Close() // nolint:errcheck
 				if body, readErr := io.ReadAll(io.LimitReader(resp.Body, 4096)); readErr == nil && len(body) > 0 {
-					return "", fmt.Errorf("start avatar session: dial websocket failed with code %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+					return nil, fmt.Errorf("start avatar session: dial websocket failed with code %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
 				}
 			}
 		}
-		return "", fmt.Errorf("start avatar session: dial websocket: %w", err)
+		return nil, fmt.Errorf("start avatar session: dial websocket: %w", err)
 	}
+	return conn, nil
+}
 
+// attachConnLocked publishes an established connection and starts its writer.
+// Callers must hold s.mu.
+func (s *AvatarSession) attachConnLocked(conn *websocket.Conn, connectionID string) {
 	s.conn = conn
-
-	// v2 handshake:
-	// 1) client sends ClientConfigureSession
-	// 2) server responds with ServerConfirmSession (connection_id) OR ServerError
-	if err := s.sendClientConfigureSession(); err != nil {
-		_ = conn.Close()
-		s.conn = nil
-		return "", err
-	}
-
-	connectionID, err := s.awaitServerConfirmSession(ctx)
-	if err != nil {
-		_ = conn.Close()
-		s.conn = nil
-		return "", err
-	}
-
+	s.writer = newConnWriter(conn, connWriterOptions{
+		writeTimeout: s.config.WriteTimeout,
+		queueSize:    s.config.OutboundQueueSize,
+		queuePolicy:  s.config.OutboundQueuePolicy,
+	})
 	s.connectionID = connectionID
-
-	// Start read loop in background
-	go s.readLoop(ctx)
-
-	return connectionID, nil
+	s.startKeepalive(conn, s.writer)
 }
 
 // sendClientConfigureSession sends the v2 handshake configuration message.
-func (s *AvatarSession) sendClientConfigureSession() error {
-	if s.conn == nil {
+func (s *AvatarSession) sendClientConfigureSession(conn *websocket.Conn) error {
+	if conn == nil {
 		return errors.New("websocket connection is not established")
 	}
 
@@ -277,7 +481,7 @@ func (s *AvatarSession) sendClientConfigureSession() error {
 		return fmt.Errorf("start avatar session: marshal configure session message: %w", err)
 	}
 
-	if err := s.conn.WriteMessage(websocket.BinaryMessage, data); err != nil {
+	if err := conn.WriteMessage(websocket.BinaryMessage, data); err != nil {
 		return fmt.Errorf("start avatar session: send configure session message: %w", err)
 	}
 
@@ -285,20 +489,20 @@ func (s *AvatarSession) sendClientConfigureSession() error {
 }
 
 // awaitServerConfirmSession waits for the server's handshake response.
-func (s *AvatarSession) awaitServerConfirmSession(ctx context.Context) (string, error) {
-	if s.conn == nil {
+func (s *AvatarSession) awaitServerConfirmSession(ctx context.Context, conn *websocket.Conn) (string, error) {
+	if conn == nil {
 		return "", errors.New("websocket connection is not established")
 	}
 
 	// Set read deadline based on context
 	if deadline, ok := ctx.Deadline(); ok {
-		if err := s.conn.SetReadDeadline(deadline); err != nil {
+		if err := conn.SetReadDeadline(deadline); err != nil {
 			return "", fmt.Errorf("start avatar session: set read deadline: %w", err)
 		}
-		defer s.conn.SetReadDeadline(time.Time{}) // nolint:errcheck
+		defer conn.SetReadDeadline(time.Time{}) // nolint:errcheck
 	}
 
-	messageType, payload, err := s.conn.ReadMessage()
+	messageType, payload, err := s.readMessage(conn)
 	if err != nil {
 		return "", fmt.Errorf("start avatar session: failed during websocket handshake: %w", err)
 	}
@@ -349,49 +553,238 @@ func (s *AvatarSession) awaitServerConfirmSession(ctx context.Context) (string,
 
 // SendAudio sends audio data to the server.
 // Audio must match the session's negotiated format unless the internal Ogg Opus encoder is enabled.
+// Consecutive calls share one request ID until a call with end=true; the ID is returned.
+// The write is bounded only by the session's WriteTimeout; use SendAudioContext to bound it per call.
 func (s *AvatarSession) SendAudio(audio []byte, end bool) (string, error) {
-	if s.conn == nil {
-		return "", errors.New("send audio: websocket connection is not established")
+	return s.SendAudioContext(context.Background(), audio, end)
+}
+
+// SendAudioContext is like SendAudio but stops waiting when ctx is done.
+// The context deadline, or the session WriteTimeout if it is sooner, is applied as the
+// websocket write deadline. If ctx ends before the chunk is written the chunk is discarded
+// and the context error is returned. PCM and pre-encoded requests stay open for further
+// chunks; with the internal Ogg Opus encoder the discarded chunk breaks the request's Ogg
+// stream, so the request fails with ErrAudioStreamBroken and the next call starts a new one.
+// With WithOutboundQueue the call returns once the chunk is queued, and ctx only bounds
+// the wait for queue space.
+func (s *AvatarSession) SendAudioContext(ctx context.Context, audio []byte, end bool) (string, error) {
+	if err := s.acquireSend(ctx); err != nil {
+		return "", fmt.Errorf("send audio: %w", err)
+	}
+	defer s.releaseSend()
+
+	s.mu.Lock()
+	req := s.current
+	// A draining session still accepts the rest of the request that is already in progress.
+	if req == nil || s.state != SessionStateDraining {
+		if err := s.checkReadyLocked("send audio"); err != nil {
+			s.mu.Unlock()
+			return "", err
+		}
+	}
+	started := req == nil
+	if started {
+		var err error
+		req, err = s.newRequestLocked("")
+		if err != nil {
+			s.mu.Unlock()
+			return "", fmt.Errorf("send audio: %w", err)
+		}
+		s.current = req
+	}
+	s.mu.Unlock()
+
+	if started {
+		s.requestStarted(req)
+	}
+
+	if err := s.writeAudio(ctx, req, audio, end); err != nil {
+		return "", fmt.Errorf("send audio: %w", err)
+	}
+	return req.id, nil
+}
+
+// BeginRequest starts a new request on the active connection.
+// Audio is streamed with Request.Write and terminated with Request.End; animation frames
+// and server errors for the request are routed to the returned handle.
+//
+// Any number of requests may be in flight at once, each with its own encoder and lifecycle,
+// up to the WithMaxConcurrentRequests limit. The request ID is generated with GenerateLogID
+// unless WithRequestID supplies one.
+func (s *AvatarSession) BeginRequest(opts ...RequestOption) (*Request, error) {
+	return s.startRequest("begin request", opts)
+}
+
+// startRequest registers a new explicit request on the active connection on behalf of op.
+func (s *AvatarSession) startRequest(op string, opts []RequestOption) (*Request, error) {
+	if s == nil {
+		return nil, fmt.Errorf("%s: session is nil", op)
+	}
+
+	var options requestOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&options)
+		}
 	}
 
-	var err error
-	if s.currentReqID == "" {
-		s.currentReqID, err = GenerateLogID()
+	s.mu.Lock()
+	if err := s.checkReadyLocked(op); err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	req, err := s.newRequestLocked(options.id)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.requestStarted(req)
+	return req, nil
+}
+
+// newRequestLocked registers a request with reqID, or a freshly generated ID if it is empty.
+// Callers must hold s.mu.
+func (s *AvatarSession) newRequestLocked(reqID string) (*Request, error) {
+	if limit := s.config.MaxConcurrentRequests; limit > 0 && len(s.requests) >= limit {
+		return nil, fmt.Errorf("%w: limit is %d", ErrTooManyRequests, limit)
+	}
+	if reqID == "" {
+		var err error
+		reqID, err = GenerateLogID()
 		if err != nil {
-			return "", fmt.Errorf("send audio: generate request id: %w", err)
+			return nil, fmt.Errorf("generate request id: %w", err)
+		}
+	} else if _, exists := s.requests[reqID]; exists {
+		return nil, fmt.Errorf("%w: %s", ErrDuplicateRequestID, reqID)
+	} else if interruption := s.interrupts[reqID]; interruption != nil {
+		if interruption.Outcome() == "" {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateRequestID, reqID)
 		}
-		s.lastReqID = s.currentReqID
+		// Stop filtering frames for a completed interruption now that the ID is reused.
+		delete(s.interrupts, reqID)
+	}
+
+	req := newRequest(s, reqID)
+	s.requests[reqID] = req
+	s.lastReqID = reqID
+	return req, nil
+}
+
+// sendRequestAudio sends one audio chunk for an explicit request handle.
+func (s *AvatarSession) sendRequestAudio(ctx context.Context, req *Request, audio []byte, end bool) error {
+	if err := s.acquireSend(ctx); err != nil {
+		return fmt.Errorf("send audio: %w", err)
+	}
+	defer s.releaseSend()
+
+	if err := req.Err(); err != nil {
+		return fmt.Errorf("send audio: %w", err)
+	}
+	if err := s.writeAudio(ctx, req, audio, end); err != nil {
+		if end && !req.audioEnded {
+			// Without end=true the server never completes the request, so release it now.
+			s.failRequestAudio(req, err)
+		}
+		return fmt.Errorf("send audio: %w", err)
+	}
+	return nil
+}
+
+// acquireSend takes the send lock, giving up when ctx is done.
+func (s *AvatarSession) acquireSend(ctx context.Context) error {
+	select {
+	case s.sendLock <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *AvatarSession) releaseSend() {
+	<-s.sendLock
+}
+
+// sendMessage writes data on writer. With the outbound queue enabled it returns as soon as the
+// message is queued; otherwise it waits for the write. droppable marks audio that the
+// drop-oldest-audio policy may discard.
+func (s *AvatarSession) sendMessage(ctx context.Context, writer *connWriter, data []byte, droppable bool) error {
+	if s.config.OutboundQueueSize > 0 {
+		return writer.post(ctx, websocket.BinaryMessage, data, droppable)
+	}
+	return writer.write(ctx, websocket.BinaryMessage, data)
+}
+
+// OutboundQueueStats returns a snapshot of the outbound queue for the current connection.
+// Counters restart when the session reconnects.
+func (s *AvatarSession) OutboundQueueStats() OutboundQueueStats {
+	if s == nil {
+		return OutboundQueueStats{}
+	}
+	s.mu.Lock()
+	writer := s.writer
+	s.mu.Unlock()
+
+	if writer == nil {
+		return OutboundQueueStats{Capacity: max(s.config.OutboundQueueSize, 0)}
+	}
+	return writer.stats()
+}
+
+// writeAudio encodes and writes one audio chunk for req.
+// Callers must hold the send lock and must not hold s.mu.
+func (s *AvatarSession) writeAudio(ctx context.Context, req *Request, audio []byte, end bool) error {
+	if req.audioEnded {
+		return fmt.Errorf("request %s already ended", req.id)
 	}
 
-	reqID := s.currentReqID
+	s.mu.Lock()
+	writer := s.writer
+	connectionID := s.connectionID
+	s.mu.Unlock()
+	if writer == nil {
+		return errors.New("websocket connection is not established")
+	}
+	s.traceRequest(ctx, req, connectionID)
+
 	payload := audio
 	var encodedStream []byte
 
-	useInternalEncoder := s.usesInternalOggOpusEncoder()
-	if useInternalEncoder {
-		encoder, err := s.getOrCreateAudioEncoder()
-		if err != nil {
-			return "", fmt.Errorf("send audio: %w", err)
+	if s.usesInternalOggOpusEncoder() {
+		if req.encoder == nil {
+			encoder, err := newOggOpusStreamEncoder(
+				s.config.SampleRate,
+				s.config.Bitrate,
+				s.config.OggOpusEncoder,
+				s.wantsEncodedAudio(),
+				s.fallbackLogger().With("req_id", req.id),
+			)
+			if err != nil {
+				return err
+			}
+			req.encoder = encoder
 		}
 
-		encodedChunk, err := encoder.Encode(audio, end)
+		encodedChunk, err := req.encoder.Encode(audio, end)
 		if err != nil {
-			return "", fmt.Errorf("send audio: %w", err)
+			return err
 		}
 
 		payload = encodedChunk.Payload
 		encodedStream = encodedChunk.CompletedStream
-	}
 
-	if useInternalEncoder && len(payload) == 0 && !end {
-		return reqID, nil
+		if len(payload) == 0 && !end {
+			// The encoder buffered the chunk; it still counts as sent input.
+			s.recordAudioSent(req, len(audio), 0, end)
+			return nil
+		}
 	}
 
 	msg := &message.Message{
 		Type: message.MessageType_MESSAGE_CLIENT_AUDIO_INPUT,
 		Data: &message.Message_ClientAudioInput{
 			ClientAudioInput: &message.ClientAudioInput{
-				ReqId: reqID,
+				ReqId: req.id,
 				Audio: payload,
 				End:   end,
 			},
@@ -400,85 +793,351 @@ func (s *AvatarSession) SendAudio(audio []byte, end bool) (string, error) {
 
 	data, err := proto.Marshal(msg)
 	if err != nil {
-		return "", fmt.Errorf("send audio: marshal message: %w", err)
+		return fmt.Errorf("marshal message: %w", err)
 	}
 
-	if err := s.conn.WriteMessage(websocket.BinaryMessage, data); err != nil {
-		return "", fmt.Errorf("send audio: write message: %w", err)
+	if err := s.sendMessage(ctx, writer, data, !end); err != nil {
+		if req.encoder != nil {
+			// The encoder has already advanced past this chunk, and it may have carried the
+			// stream headers, so the rest of the request's Ogg stream cannot be sent intact.
+			s.failRequestAudio(req, fmt.Errorf("%w: %w", ErrAudioStreamBroken, err))
+		}
+		return fmt.Errorf("write message: %w", err)
 	}
+	s.recordAudioSent(req, len(audio), len(payload), end)
 
 	if len(encodedStream) > 0 {
-		s.notifyEncodedAudio(reqID, encodedStream)
+		s.notifyEncodedAudio(req.id, encodedStream)
 	}
 
 	if end {
-		s.currentReqID = ""
-		s.audioEncoder = nil
+		req.audioEnded = true
+		req.encoder = nil
+		s.currentConnLogger().Debug("request audio ended", "req_id", req.id)
+
+		s.mu.Lock()
+		if s.current == req {
+			s.current = nil
+		}
+		egress := s.usesEgress()
+		if egress {
+			delete(s.requests, req.id)
+		}
+		s.mu.Unlock()
+
+		// Egress sessions stream animation elsewhere, so no end frame will arrive on this connection.
+		if egress {
+			req.finish(nil)
+		}
 	}
 
-	return reqID, nil
+	return nil
+}
+
+// failRequestAudio ends req without sending end=true and finishes it with err.
+// Callers must hold the send lock and must not hold s.mu.
+func (s *AvatarSession) failRequestAudio(req *Request, err error) {
+	req.audioEnded = true
+	req.encoder = nil
+
+	s.mu.Lock()
+	if s.current == req {
+		s.current = nil
+	}
+	if s.requests[req.id] == req {
+		delete(s.requests, req.id)
+	}
+	s.mu.Unlock()
+
+	s.currentConnLogger().Debug("request audio failed", "req_id", req.id, "error", err)
+	req.finish(err)
 }
 
 // Interrupt sends an interrupt signal to stop the current audio processing.
 // Returns the request ID that was interrupted, or empty string if no request was active.
+// Late frames for the interrupted request are filtered like with InterruptRequest.
 func (s *AvatarSession) Interrupt() (string, error) {
-	if s.conn == nil {
-		return "", errors.New("interrupt: websocket connection is not established")
-	}
+	return s.InterruptContext(context.Background())
+}
 
+// InterruptContext is like Interrupt but stops waiting when ctx is done.
+// The context deadline, or the session WriteTimeout if it is sooner, is applied as the
+// websocket write deadline. Interrupts are queued like audio when WithOutboundQueue is
+// set but are never dropped.
+func (s *AvatarSession) InterruptContext(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	writer := s.writer
 	// Use lastReqID which tracks the most recent request, even after end=true
 	reqID := s.lastReqID
+	s.mu.Unlock()
+
+	if writer == nil {
+		return "", errors.New("interrupt: websocket connection is not established")
+	}
 	if reqID == "" {
 		return "", errors.New("interrupt: no request to interrupt")
 	}
 
-	msg := &message.Message{
-		Type: message.MessageType_MESSAGE_CLIENT_INTERRUPT,
-		Data: &message.Message_ClientInterrupt{
-			ClientInterrupt: &message.ClientInterrupt{
-				ReqId: reqID,
-			},
-		},
+	if _, err := s.interrupt(ctx, "interrupt", reqID); err != nil {
+		return "", err
 	}
+	return reqID, nil
+}
 
-	data, err := proto.Marshal(msg)
-	if err != nil {
-		return "", fmt.Errorf("interrupt: marshal message: %w", err)
+// Close closes the WebSocket connection and cleans up resources.
+func (s *AvatarSession) Close() error {
+	if s == nil {
+		return nil
 	}
+	return s.closeConn(nil)
+}
 
-	if err := s.conn.WriteMessage(websocket.BinaryMessage, data); err != nil {
-		return "", fmt.Errorf("interrupt: write message: %w", err)
+// Shutdown gracefully closes the session. It stops accepting new requests, waits until every
+// outstanding request has received its final animation frame or an error, and then closes the
+// connection like Close. Requests that already started may still send their remaining audio
+// while the session drains.
+//
+// If ctx ends first the session is closed anyway, outstanding requests fail with
+// ErrSessionClosed, and the context error is returned.
+func (s *AvatarSession) Shutdown(ctx context.Context) error {
+	if s == nil {
+		return nil
 	}
 
-	// Clear current request ID so next SendAudio creates a new one
-	s.currentReqID = ""
+	s.mu.Lock()
+	switch s.state {
+	case SessionStateClosed:
+		s.mu.Unlock()
+		return nil
+	case SessionStateReady:
+		s.setStateLocked(SessionStateDraining)
+	}
+	pending := make([]*Request, 0, len(s.requests))
+	for _, req := range s.requests {
+		pending = append(pending, req)
+	}
+	s.mu.Unlock()
+
+	var waitErr error
+wait:
+	for _, req := range pending {
+		select {
+		case <-req.Done():
+		case <-ctx.Done():
+			waitErr = ctx.Err()
+			break wait
+		}
+	}
 
-	return reqID, nil
+	if err := s.closeConn(nil); err != nil {
+		return err
+	}
+	if waitErr != nil {
+		return fmt.Errorf("shutdown avatar session: %w", waitErr)
+	}
+	return nil
 }
 
-// Close closes the WebSocket connection and cleans up resources.
-func (s *AvatarSession) Close() error {
-	if s == nil {
+// closeConn tears down the active connection. When expected is non-nil the
+// connection is only closed if it is still the session's active connection,
+// which keeps a stale read loop from closing a newer connection.
+func (s *AvatarSession) closeConn(expected *websocket.Conn) error {
+	s.mu.Lock()
+	if s.state == SessionStateClosed || (expected != nil && s.conn != expected) {
+		s.mu.Unlock()
 		return nil
 	}
-	if s.conn != nil {
-		err := s.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	detached := s.detachLocked()
+	s.mu.Unlock()
+
+	err := s.teardown(detached, nil)
+	s.currentConnLogger().Debug("avatar session closed")
+	return err
+}
+
+// detachedConn holds the state removed from a session that is closing.
+type detachedConn struct {
+	conn       *websocket.Conn
+	writer     *connWriter
+	dispatcher *dispatcher
+	requests   []*Request
+	interrupts []*Interruption
+}
+
+// detachLocked closes the session and clears the connection state, cancelling any
+// reconnect or token refresh in progress.
+// Callers must hold s.mu.
+func (s *AvatarSession) detachLocked() detachedConn {
+	// Queue the state change before the dispatcher is detached so it precedes OnClose.
+	s.setStateLocked(SessionStateClosed)
+	detached := detachedConn{
+		conn:       s.conn,
+		writer:     s.writer,
+		dispatcher: s.dispatcher,
+		requests:   s.takeRequestsLocked(),
+		interrupts: s.takeInterruptsLocked(),
+	}
+	if s.reconnecting != nil {
+		s.reconnecting.cancel()
+		s.reconnecting = nil
+	}
+	s.stopTokenRefreshLocked()
+	s.conn = nil
+	s.writer = nil
+	s.dispatcher = nil
+	s.current = nil
+	return detached
+}
+
+// teardown closes detached connection state and notifies OnClose.
+// If finalErr is non-nil it is reported through OnError first.
+func (s *AvatarSession) teardown(detached detachedConn, finalErr error) error {
+	for _, req := range detached.requests {
+		req.finish(ErrSessionClosed)
+	}
+	for _, interruption := range detached.interrupts {
+		interruption.finish(InterruptOutcomeClosed, ErrSessionClosed)
+	}
+
+	var closeErr error
+	if detached.writer != nil {
+		detached.writer.close()
+	}
+	if conn := detached.conn; conn != nil {
+		// WriteControl may be called concurrently with the writer goroutine.
+		err := conn.WriteControl(
+			websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+			time.Now().Add(closeMessageTimeout),
+		)
 		if err != nil {
-			_ = s.conn.Close()
-			s.conn = nil
-			return fmt.Errorf("close avatar session: send close message: %w", err)
+			_ = conn.Close()
+			closeErr = fmt.Errorf("close avatar session: send close message: %w", err)
+		} else if err := conn.Close(); err != nil {
+			closeErr = fmt.Errorf("close avatar session: close connection: %w", err)
 		}
-		err = s.conn.Close()
-		if err != nil {
-			s.conn = nil
-			return fmt.Errorf("close avatar session: close connection: %w", err)
+	}
+
+	d := detached.dispatcher
+	if s.config != nil {
+		if d == nil {
+			d = newDispatcher(0, DispatchOverflowBlock)
 		}
-		s.conn = nil
+		if finalErr != nil {
+			d.enqueue(func() {
+				if s.config.OnError != nil {
+					s.invokeCallback("OnError", func() { s.config.OnError(finalErr) })
+				}
+				s.publish(ErrorEvent{Err: finalErr})
+			})
+		}
+		// OnClose is queued behind any callbacks still pending for this connection.
+		d.enqueue(func() {
+			if s.config.OnClose != nil {
+				s.invokeCallback("OnClose", s.config.OnClose)
+			}
+			s.closeEvents()
+		})
 	}
-	if s.config != nil && s.config.OnClose != nil {
-		go s.config.OnClose()
+	if d != nil {
+		d.close()
 	}
-	return nil
+	return closeErr
+}
+
+// dispatchFrom queues a user callback on behalf of conn's read loop. Callbacks are
+// delivered in order by the session dispatcher; callbacks from a connection that is
+// no longer active are discarded.
+func (s *AvatarSession) dispatchFrom(conn *websocket.Conn, fn func()) {
+	s.mu.Lock()
+	if s.conn != conn {
+		s.mu.Unlock()
+		return
+	}
+	d := s.dispatcherLocked()
+	s.mu.Unlock()
+
+	d.dispatch(fn)
+}
+
+// enqueueFrom is like dispatchFrom for lifecycle notifications, which are queued regardless
+// of the queue bound and never dropped.
+func (s *AvatarSession) enqueueFrom(conn *websocket.Conn, fn func()) {
+	s.mu.Lock()
+	if s.conn != conn {
+		s.mu.Unlock()
+		return
+	}
+	d := s.dispatcherLocked()
+	s.mu.Unlock()
+
+	d.enqueue(fn)
+}
+
+// dispatcherLocked returns the session dispatcher, creating it on first use.
+// Callers must hold s.mu.
+func (s *AvatarSession) dispatcherLocked() *dispatcher {
+	if s.dispatcher == nil {
+		s.dispatcher = newDispatcher(s.config.DispatchQueueSize, s.config.DispatchOverflow)
+	}
+	return s.dispatcher
+}
+
+// takeRequestsLocked removes and returns every in-flight request.
+// Callers must hold s.mu.
+func (s *AvatarSession) takeRequestsLocked() []*Request {
+	pending := make([]*Request, 0, len(s.requests))
+	for id, req := range s.requests {
+		pending = append(pending, req)
+		delete(s.requests, id)
+	}
+	return pending
+}
+
+// completeRequest removes reqID from the in-flight set and finishes its handle.
+// Frames for unknown or already completed request IDs are ignored.
+func (s *AvatarSession) completeRequest(reqID string, err error) {
+	s.mu.Lock()
+	req := s.requests[reqID]
+	delete(s.requests, reqID)
+	s.mu.Unlock()
+
+	if req != nil {
+		req.finish(err)
+	}
+}
+
+// lookupRequest returns the in-flight request with the given ID, if any.
+func (s *AvatarSession) lookupRequest(reqID string) *Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[reqID]
+}
+
+// failRequests finishes every in-flight request with err if conn is still the active connection.
+func (s *AvatarSession) failRequests(conn *websocket.Conn, err error) {
+	s.mu.Lock()
+	if s.conn != conn {
+		s.mu.Unlock()
+		return
+	}
+	pending := s.takeRequestsLocked()
+	interrupts := s.takeInterruptsLocked()
+	s.mu.Unlock()
+
+	for _, req := range pending {
+		req.finish(err)
+	}
+	for _, interruption := range interrupts {
+		interruption.finish(InterruptOutcomeClosed, err)
+	}
+}
+
+// isActiveConn reports whether conn is still the session's active connection.
+func (s *AvatarSession) isActiveConn(conn *websocket.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn == conn
 }
 
 type sessionTokenRequest struct {
@@ -506,17 +1165,18 @@ func formatSessionTokenError(status int, resp *sessionTokenResponse) string {
 	return fmt.Sprintf("Error %d (%s): %s - %s", err.Status, err.Code, err.Title, err.Detail)
 }
 
-func (s *AvatarSession) readLoop(ctx context.Context) {
-	if s == nil {
-		return
-	}
-
-	conn := s.conn
-	if conn == nil {
+func (s *AvatarSession) readLoop(ctx context.Context, conn *websocket.Conn) {
+	if s == nil || conn == nil {
 		return
 	}
 
 	cfg := s.config
+	sequencer := newFrameSequencer()
+
+	s.mu.Lock()
+	connectionID := s.connectionID
+	s.mu.Unlock()
+	logger := s.connLogger(connectionID)
 
 	for {
 		if ctx != nil {
@@ -527,22 +1187,31 @@ func (s *AvatarSession) readLoop(ctx context.Context) {
 			}
 		}
 
-		messageType, payload, err := conn.ReadMessage()
+		messageType, payload, err := s.readMessage(conn)
 		if err != nil {
 			if ctx != nil && ctx.Err() != nil {
 				return
 			}
 
-			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+			// The session closed or replaced this connection; the read error is expected.
+			// A close frame from the server on the active connection is a drop like any other.
+			if !s.isActiveConn(conn) {
 				return
 			}
 
-			if cfg != nil && cfg.OnError != nil {
-				asyncErr := fmt.Errorf("avatar session read loop: read message: %w", err)
-				go cfg.OnError(asyncErr)
+			var asyncErr error = fmt.Errorf("avatar session read loop: read message: %w", err)
+			if timeoutErr := s.keepaliveTimeout(err, connectionID); timeoutErr != nil {
+				asyncErr = timeoutErr
 			}
+			logger.Warn("avatar session connection lost", "error", asyncErr)
+			s.reportErrorFrom(conn, asyncErr)
 
-			_ = s.Close()
+			s.failRequests(conn, fmt.Errorf("%w: %v", ErrSessionClosed, asyncErr))
+			if cfg != nil && cfg.Reconnect != nil {
+				s.reconnect(ctx, conn, asyncErr)
+				return
+			}
+			_ = s.closeConn(conn)
 			return
 		}
 
@@ -552,36 +1221,55 @@ func (s *AvatarSession) readLoop(ctx context.Context) {
 
 		var envelope message.Message
 		if err := proto.Unmarshal(payload, &envelope); err != nil {
-			if cfg != nil && cfg.OnError != nil {
-				asyncErr := fmt.Errorf("avatar session read loop: decode message: %w", err)
-				go cfg.OnError(asyncErr)
-			}
+			logger.Warn("failed to decode server message", "error", err)
+			s.reportErrorFrom(conn, fmt.Errorf("avatar session read loop: decode message: %w", err))
 			continue
 		}
 
 		switch envelope.GetType() {
 		case message.MessageType_MESSAGE_SERVER_RESPONSE_ANIMATION:
-			if cfg != nil && cfg.TransportFrames != nil {
-				frame := append([]byte(nil), payload...)
-				anim := envelope.GetServerResponseAnimation()
-				last := anim != nil && anim.GetEnd()
-				go cfg.TransportFrames(frame, last)
+			frame := sequencer.frame(envelope.GetServerResponseAnimation(), payload, s.timeNow())
+			if s.filterInterruptedFrame(frame) {
+				continue
 			}
-		case message.MessageType_MESSAGE_SERVER_ERROR:
-			if cfg != nil && cfg.OnError != nil {
-				serverErr := envelope.GetServerError()
-				if serverErr == nil {
-					go cfg.OnError(errors.New("avatar session read loop: error message missing payload"))
-					continue
+			s.dispatchFrom(conn, func() {
+				if cfg != nil && cfg.TransportFrames != nil {
+					s.invokeCallback("TransportFrames", func() { cfg.TransportFrames(frame.Payload, frame.End) })
+				}
+				if cfg != nil && cfg.OnAnimationFrame != nil {
+					s.invokeCallback("OnAnimationFrame", func() { cfg.OnAnimationFrame(frame) })
+				}
+				s.publish(FrameEvent{Frame: frame})
+			})
+			s.recordFrame(frame)
+			if frame.End {
+				logger.Debug("request completed", "req_id", frame.ReqID, "frames", frame.Seq+1)
+			}
+			if req := s.lookupRequest(frame.ReqID); req != nil {
+				req.deliver(frame)
+				if frame.End {
+					s.completeRequest(frame.ReqID, nil)
 				}
-				report := newServerAvatarSDKError(
-					"runtime",
-					serverErr.GetCode(),
-					serverErr.GetMessage(),
-					serverErr.GetConnectionId(),
-					serverErr.GetReqId(),
-				)
-				go cfg.OnError(report)
+			}
+		case message.MessageType_MESSAGE_SERVER_ERROR:
+			serverErr := envelope.GetServerError()
+			if serverErr == nil {
+				s.reportErrorFrom(conn, errors.New("avatar session read loop: error message missing payload"))
+				continue
+			}
+			report := newServerAvatarSDKError(
+				"runtime",
+				serverErr.GetCode(),
+				serverErr.GetMessage(),
+				serverErr.GetConnectionId(),
+				serverErr.GetReqId(),
+			)
+			logger.Warn("server reported error", "req_id", report.ReqID, "code", report.ServerCode, "error", report.Message)
+			s.reportErrorFrom(conn, report)
+			if report.ReqID != "" {
+				sequencer.forget(report.ReqID)
+				s.interruptFailed(report.ReqID, report)
+				s.completeRequest(report.ReqID, report)
 			}
 		}
 	}
@@ -605,35 +1293,88 @@ func (s *AvatarSession) usesInternalOggOpusEncoder() bool {
 		s.config.OggOpusEncoder != nil
 }
 
-func (s *AvatarSession) getOrCreateAudioEncoder() (*OggOpusStreamEncoder, error) {
-	if s.audioEncoder != nil {
-		return s.audioEncoder, nil
+// httpClient returns the client used for console API requests.
+func (s *AvatarSession) httpClient() *http.Client {
+	if s.config.HTTPClient != nil {
+		return s.config.HTTPClient
 	}
+	return http.DefaultClient
+}
 
-	encoder, err := NewOggOpusStreamEncoder(
-		s.config.SampleRate,
-		s.config.Bitrate,
-		s.config.OggOpusEncoder,
-		s.config.OnEncodedAudio != nil,
-	)
+// webSocketDialer returns the dialer used to connect to the ingress.
+func (s *AvatarSession) webSocketDialer() *websocket.Dialer {
+	dialer := websocket.DefaultDialer
+	if s.config.WebSocketDialer != nil {
+		dialer = s.config.WebSocketDialer
+	}
+	if s.config.ReadBufferSize <= 0 && s.config.WriteBufferSize <= 0 {
+		return dialer
+	}
+	sized := *dialer
+	if s.config.ReadBufferSize > 0 {
+		sized.ReadBufferSize = s.config.ReadBufferSize
+	}
+	if s.config.WriteBufferSize > 0 {
+		sized.WriteBufferSize = s.config.WriteBufferSize
+	}
+	return &sized
+}
+
+// readMessage reads the next message from conn, enforcing SessionConfig.MaxMessageSize.
+// Reading stops one byte past the limit so an endless message cannot stall the read loop: the
+// connection is closed with CloseMessageTooBig right away, and a MessageTooLargeError with the
+// bytes read so far as a lower bound of the message size is returned.
+func (s *AvatarSession) readMessage(conn *websocket.Conn) (int, []byte, error) {
+	limit := s.config.MaxMessageSize
+	if limit <= 0 {
+		return conn.ReadMessage()
+	}
+
+	messageType, r, err := conn.NextReader()
 	if err != nil {
-		return nil, err
+		return messageType, nil, err
+	}
+	payload, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return messageType, nil, err
+	}
+	if int64(len(payload)) <= limit {
+		return messageType, payload, nil
 	}
 
-	s.audioEncoder = encoder
-	return s.audioEncoder, nil
+	_ = conn.WriteControl(
+		websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseMessageTooBig, ""),
+		time.Now().Add(closeMessageTimeout),
+	)
+	_ = conn.Close()
+	return messageType, nil, &MessageTooLargeError{
+		Size:       int64(len(payload)),
+		Limit:      limit,
+		Incomplete: true,
+	}
 }
 
-func (s *AvatarSession) notifyEncodedAudio(reqID string, encodedAudio []byte) {
-	if s == nil || s.config == nil || s.config.OnEncodedAudio == nil {
-		return
+// timeNow returns the session clock's current time.
+func (s *AvatarSession) timeNow() time.Time {
+	if s.now != nil {
+		return s.now()
 	}
+	return time.Now()
+}
 
-	defer func() {
-		if recovered := recover(); recovered != nil {
-			log.Printf("avatarsdkgo: on encoded audio callback panicked: %v", recovered)
-		}
-	}()
+// usesEgress reports whether animation is streamed to an egress service instead of this connection.
+func (s *AvatarSession) usesEgress() bool {
+	return s.config.LiveKitEgress != nil || s.config.AgoraEgress != nil
+}
 
-	s.config.OnEncodedAudio(reqID, encodedAudio)
+func (s *AvatarSession) notifyEncodedAudio(reqID string, encodedAudio []byte) {
+	if s == nil || s.config == nil {
+		return
+	}
+	s.publish(EncodedAudioEvent{ReqID: reqID, Audio: encodedAudio})
+	if s.config.OnEncodedAudio == nil {
+		return
+	}
+	s.invokeCallback("OnEncodedAudio", func() { s.config.OnEncodedAudio(reqID, encodedAudio) })
 }
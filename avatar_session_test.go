@@ -5,9 +5,14 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -68,6 +73,55 @@ func TestAvatarSessionInitSuccess(t *testing.T) {
 	}
 }
 
+func TestAvatarSessionInitModelVersion(t *testing.T) {
+	bodies := make(chan map[string]any, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode request payload: %v", err)
+		}
+		bodies <- body
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(sessionTokenResponse{SessionToken: "session-token-123"})
+	}))
+	defer server.Close()
+
+	for _, tc := range []struct {
+		name    string
+		opts    []SessionOption
+		version string
+	}{
+		{name: "selected", opts: []SessionOption{WithModelVersion("v2.1")}, version: "v2.1"},
+		{name: "default"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			session := NewAvatarSession(append([]SessionOption{
+				WithAPIKey("api-key"),
+				WithExpireAt(time.Now().Add(time.Hour)),
+				WithConsoleEndpointURL(server.URL),
+			}, tc.opts...)...)
+			if got := session.ModelVersion(); got != "" {
+				t.Fatalf("expected no model version before Init, got %q", got)
+			}
+			if err := session.Init(context.Background()); err != nil {
+				t.Fatalf("Init returned error: %v", err)
+			}
+
+			body := <-bodies
+			version, sent := body["modelVersion"]
+			if tc.version == "" && sent {
+				t.Fatalf("expected modelVersion to be omitted, got %v", version)
+			}
+			if tc.version != "" && version != tc.version {
+				t.Fatalf("expected modelVersion %q in the request body, got %v", tc.version, body)
+			}
+			if got := session.ModelVersion(); got != tc.version {
+				t.Fatalf("expected ModelVersion %q after Init, got %q", tc.version, got)
+			}
+		})
+	}
+}
+
 func TestAvatarSessionInitFailure(t *testing.T) {
 	expireAt := time.Unix(1754824283, 0).UTC()
 
@@ -1060,7 +1114,7 @@ func TestAvatarSessionSendAudioOggOpusPassthroughKeepsPreEncodedBytes(t *testing
 	defer clientConn.Close() // nolint:errcheck
 
 	session := NewAvatarSession(WithAudioFormat(AudioFormatOggOpus))
-	session.conn = clientConn
+	attachTestConn(session, clientConn)
 	defer func() {
 		if err := session.Close(); err != nil {
 			t.Fatalf("failed to close session: %v", err)
@@ -1145,7 +1199,7 @@ func TestAvatarSessionSendAudioInternalEncoderOutputsOggOpusAndCallback(t *testi
 			callbackPayload = append([]byte(nil), payload...)
 		}),
 	)
-	session.conn = clientConn
+	attachTestConn(session, clientConn)
 	defer func() {
 		if err := session.Close(); err != nil {
 			t.Fatalf("failed to close session: %v", err)
@@ -1228,7 +1282,7 @@ func TestAvatarSessionSendAudioInternalEncoderBuffersUntilFrameReady(t *testing.
 		WithSampleRate(24000),
 		WithOggOpusEncoder(nil),
 	)
-	session.conn = clientConn
+	attachTestConn(session, clientConn)
 	defer func() {
 		if err := session.Close(); err != nil {
 			t.Fatalf("failed to close session: %v", err)
@@ -1642,7 +1696,7 @@ func TestReqIDGeneration(t *testing.T) {
 	defer clientConn.Close() // nolint:errcheck
 
 	session := NewAvatarSession()
-	session.conn = clientConn
+	attachTestConn(session, clientConn)
 	defer func() {
 		if err := session.Close(); err != nil {
 			t.Fatalf("failed to close session: %v", err)
@@ -1738,3 +1792,882 @@ func TestReqIDGeneration(t *testing.T) {
 		t.Fatalf("expected server to receive req id %q for fourth chunk, got %q", thirdReqID, received)
 	}
 }
+
+func TestAvatarSessionConcurrentSendAudioAndInterrupt(t *testing.T) {
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress)
+	defer session.Close() // nolint:errcheck
+
+	serverConn := ingress.accept(t)
+
+	const (
+		senders         = 8
+		chunksPerSender = 20
+	)
+
+	var wg sync.WaitGroup
+	sendErrs := make(chan error, senders*chunksPerSender)
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < chunksPerSender; j++ {
+				if _, err := session.SendAudio([]byte{0x01, 0x02, 0x03, 0x04}, j%5 == 4); err != nil {
+					sendErrs <- err
+				}
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			_, _ = session.Interrupt()
+		}
+	}()
+
+	wg.Wait()
+	close(sendErrs)
+	for err := range sendErrs {
+		t.Fatalf("SendAudio returned error: %v", err)
+	}
+
+	audioInputs := 0
+	for audioInputs < senders*chunksPerSender {
+		msg := serverConn.next(t)
+		if msg.GetType() == message.MessageType_MESSAGE_CLIENT_AUDIO_INPUT {
+			if !bytes.Equal(msg.GetClientAudioInput().GetAudio(), []byte{0x01, 0x02, 0x03, 0x04}) {
+				t.Fatalf("unexpected audio payload %v", msg.GetClientAudioInput().GetAudio())
+			}
+			audioInputs++
+		}
+	}
+}
+
+func TestAvatarSessionConcurrentClose(t *testing.T) {
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress)
+	serverConn := ingress.accept(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if _, err := session.SendAudio([]byte{0x01, 0x02}, false); err != nil {
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			if _, err := session.Interrupt(); err != nil && strings.Contains(err.Error(), "not established") {
+				return
+			}
+		}
+	}()
+
+	// Let the senders get going, then race a server-side drop against user closes.
+	serverConn.next(t)
+	_ = serverConn.conn.Close()
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = session.Close()
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for concurrent senders to observe Close")
+	}
+
+	if _, err := session.SendAudio([]byte{0x01, 0x02}, true); err == nil {
+		t.Fatal("expected SendAudio to fail after Close")
+	}
+}
+
+// attachTestConn installs an already dialed websocket connection as the session's active connection.
+func attachTestConn(session *AvatarSession, conn *websocket.Conn) {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	session.attachConnLocked(conn, "")
+	session.state = SessionStateReady
+}
+
+// fakeIngress is a websocket test server that completes the v2 handshake and
+// records every client message received afterwards.
+type fakeIngress struct {
+	server *httptest.Server
+	conns  chan *fakeIngressConn
+	count  atomic.Int32
+}
+
+type fakeIngressConn struct {
+	conn         *websocket.Conn
+	connectionID string
+	messages     chan *message.Message
+
+	writeMu sync.Mutex
+}
+
+func newFakeIngress(t *testing.T) *fakeIngress {
+	t.Helper()
+	return newFakeIngressWithAuth(t, nil)
+}
+
+// newFakeIngressWithAuth is like newFakeIngress but rejects upgrades for which
+// authorize returns a status other than http.StatusOK.
+func newFakeIngressWithAuth(t *testing.T, authorize func(*http.Request) int) *fakeIngress {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool { return true },
+	}
+
+	ingress := &fakeIngress{conns: make(chan *fakeIngressConn, 8)}
+	ingress.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if authorize != nil {
+			if status := authorize(r); status != http.StatusOK {
+				w.WriteHeader(status)
+				return
+			}
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close() // nolint:errcheck
+
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+
+		fc := &fakeIngressConn{
+			conn:         conn,
+			connectionID: fmt.Sprintf("conn-%d", ingress.count.Add(1)),
+			messages:     make(chan *message.Message, 1024),
+		}
+		fc.send(t, &message.Message{
+			Type: message.MessageType_MESSAGE_SERVER_CONFIRM_SESSION,
+			Data: &message.Message_ServerConfirmSession{
+				ServerConfirmSession: &message.ServerConfirmSession{ConnectionId: fc.connectionID},
+			},
+		})
+		ingress.conns <- fc
+
+		defer close(fc.messages)
+		for {
+			messageType, payload, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if messageType != websocket.BinaryMessage {
+				continue
+			}
+			var envelope message.Message
+			if err := proto.Unmarshal(payload, &envelope); err != nil {
+				t.Errorf("server received invalid protobuf payload: %v", err)
+				return
+			}
+			fc.messages <- &envelope
+		}
+	}))
+	t.Cleanup(ingress.server.Close)
+
+	return ingress
+}
+
+func (f *fakeIngress) url() string {
+	return strings.Replace(f.server.URL, "http", "ws", 1)
+}
+
+// accept waits for the next client connection to complete the handshake.
+func (f *fakeIngress) accept(t *testing.T) *fakeIngressConn {
+	t.Helper()
+	select {
+	case conn := <-f.conns:
+		return conn
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for client connection")
+	}
+	return nil
+}
+
+func (c *fakeIngressConn) send(t *testing.T, msg *message.Message) {
+	t.Helper()
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		t.Errorf("marshal server message: %v", err)
+		return
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_ = c.conn.WriteMessage(websocket.BinaryMessage, data)
+}
+
+// next returns the next client message received by the server.
+func (c *fakeIngressConn) next(t *testing.T) *message.Message {
+	t.Helper()
+	select {
+	case msg, ok := <-c.messages:
+		if !ok {
+			t.Fatal("client connection closed while waiting for message")
+		}
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for client message")
+	}
+	return nil
+}
+
+// startTestSession starts a session against ingress, bypassing the console token exchange in Init.
+func startTestSession(t *testing.T, ingress *fakeIngress, opts ...SessionOption) *AvatarSession {
+	t.Helper()
+
+	opts = append([]SessionOption{
+		WithAvatarID("avatar-123"),
+		WithAppID("app-123"),
+		WithIngressEndpointURL(ingress.url()),
+	}, opts...)
+	session := NewAvatarSession(opts...)
+	session.sessionToken = "session-token-123"
+	session.state = SessionStateInitialized
+
+	if _, err := session.Start(context.Background()); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	return session
+}
+
+func TestReadLoopDeliversFramesInWireOrder(t *testing.T) {
+	const frameCount = 200
+
+	type receivedFrame struct {
+		reqID string
+		last  bool
+	}
+	frames := make(chan receivedFrame, frameCount)
+	closed := make(chan struct{})
+
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress,
+		WithDispatchQueue(4, DispatchOverflowBlock),
+		WithTransportFrames(func(data []byte, last bool) {
+			var envelope message.Message
+			if err := proto.Unmarshal(data, &envelope); err != nil {
+				t.Errorf("unmarshal frame: %v", err)
+				return
+			}
+			frames <- receivedFrame{reqID: envelope.GetServerResponseAnimation().GetReqId(), last: last}
+		}),
+		WithOnClose(func() { close(closed) }),
+	)
+	serverConn := ingress.accept(t)
+
+	for i := 0; i < frameCount; i++ {
+		serverConn.send(t, &message.Message{
+			Type: message.MessageType_MESSAGE_SERVER_RESPONSE_ANIMATION,
+			Data: &message.Message_ServerResponseAnimation{
+				ServerResponseAnimation: &message.ServerResponseAnimation{
+					ConnectionId: serverConn.connectionID,
+					ReqId:        fmt.Sprintf("req-%03d", i),
+					End:          i == frameCount-1,
+				},
+			},
+		})
+	}
+
+	for i := 0; i < frameCount; i++ {
+		select {
+		case frame := <-frames:
+			if want := fmt.Sprintf("req-%03d", i); frame.reqID != want {
+				t.Fatalf("expected frame %q at position %d, got %q", want, i, frame.reqID)
+			}
+			if frame.last != (i == frameCount-1) {
+				t.Fatalf("unexpected last=%v at position %d", frame.last, i)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for frame %d", i)
+		}
+	}
+
+	if err := session.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for OnClose")
+	}
+}
+
+func TestAvatarSessionShutdownWaitsForOutstandingRequests(t *testing.T) {
+	frames := make(chan bool, 4)
+	closed := make(chan struct{})
+
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress,
+		WithTransportFrames(func(_ []byte, last bool) { frames <- last }),
+		WithOnClose(func() { close(closed) }),
+	)
+	serverConn := ingress.accept(t)
+
+	reqID, err := session.SendAudio([]byte{0x01, 0x02}, false)
+	if err != nil {
+		t.Fatalf("SendAudio returned error: %v", err)
+	}
+	serverConn.next(t)
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- session.Shutdown(context.Background())
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for session.State() != SessionStateDraining {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for draining state, got %q", session.State())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if _, err := session.BeginRequest(); !errors.Is(err, ErrSessionDraining) {
+		t.Fatalf("expected BeginRequest to fail with ErrSessionDraining, got %v", err)
+	}
+
+	// The request already in progress may still finish sending its audio.
+	if got, err := session.SendAudio([]byte{0x03, 0x04}, true); err != nil || got != reqID {
+		t.Fatalf("expected SendAudio to continue %q while draining, got %q, %v", reqID, got, err)
+	}
+	if msg := serverConn.next(t); !msg.GetClientAudioInput().GetEnd() {
+		t.Fatal("expected end=true audio on the server")
+	}
+
+	if _, err := session.SendAudio([]byte{0x05}, true); !errors.Is(err, ErrSessionDraining) {
+		t.Fatalf("expected new SendAudio request to fail with ErrSessionDraining, got %v", err)
+	}
+
+	select {
+	case err := <-shutdownErr:
+		t.Fatalf("Shutdown returned before the final frame: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	serverConn.send(t, animationMessage(serverConn.connectionID, reqID, true))
+
+	select {
+	case err := <-shutdownErr:
+		if err != nil {
+			t.Fatalf("Shutdown returned error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for Shutdown")
+	}
+
+	select {
+	case last := <-frames:
+		if !last {
+			t.Fatal("expected the final frame to be delivered before close")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for final frame")
+	}
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for OnClose")
+	}
+	if state := session.State(); state != SessionStateClosed {
+		t.Fatalf("expected closed state, got %q", state)
+	}
+}
+
+func TestAvatarSessionServerCloseFrameClosesSession(t *testing.T) {
+	errs := make(chan error, 4)
+	closed := make(chan struct{})
+
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress,
+		WithOnError(func(err error) { errs <- err }),
+		WithOnClose(func() { close(closed) }),
+	)
+	serverConn := ingress.accept(t)
+
+	req, err := session.BeginRequest()
+	if err != nil {
+		t.Fatalf("BeginRequest returned error: %v", err)
+	}
+	sendServerClose(t, serverConn, websocket.CloseGoingAway)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := req.Wait(ctx); !errors.Is(err, ErrSessionClosed) {
+		t.Fatalf("expected ErrSessionClosed, got %v", err)
+	}
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for OnClose")
+	}
+	if state := session.State(); state != SessionStateClosed {
+		t.Fatalf("expected closed state, got %q", state)
+	}
+	select {
+	case err := <-errs:
+		var closeErr *websocket.CloseError
+		if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseGoingAway {
+			t.Fatalf("expected the close frame to be reported, got %v", err)
+		}
+	default:
+		t.Fatal("expected OnError for the server close")
+	}
+	if err := session.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown after server close returned error: %v", err)
+	}
+}
+
+// sendServerClose makes the fake ingress close the connection with a close frame.
+func sendServerClose(t *testing.T, serverConn *fakeIngressConn, code int) {
+	t.Helper()
+
+	err := serverConn.conn.WriteControl(
+		websocket.CloseMessage,
+		websocket.FormatCloseMessage(code, ""),
+		time.Now().Add(time.Second),
+	)
+	if err != nil {
+		t.Fatalf("failed to send close frame: %v", err)
+	}
+}
+
+func TestAvatarSessionShutdownContextExpires(t *testing.T) {
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress)
+	ingress.accept(t)
+
+	req, err := session.BeginRequest()
+	if err != nil {
+		t.Fatalf("BeginRequest returned error: %v", err)
+	}
+	if err := req.End(); err != nil {
+		t.Fatalf("End returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := session.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected Shutdown to report context.DeadlineExceeded, got %v", err)
+	}
+	if state := session.State(); state != SessionStateClosed {
+		t.Fatalf("expected closed state, got %q", state)
+	}
+	if err := req.Wait(context.Background()); !errors.Is(err, ErrSessionClosed) {
+		t.Fatalf("expected outstanding request to fail with ErrSessionClosed, got %v", err)
+	}
+}
+
+func TestAvatarSessionShutdownWithoutConnection(t *testing.T) {
+	session := NewAvatarSession()
+	if err := session.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown returned error: %v", err)
+	}
+	if state := session.State(); state != SessionStateClosed {
+		t.Fatalf("expected closed state, got %q", state)
+	}
+}
+
+func TestAvatarSessionSendAudioContextStopsWaitingForStalledSender(t *testing.T) {
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress)
+	defer session.Close() // nolint:errcheck
+	serverConn := ingress.accept(t)
+
+	// Simulate another goroutine stuck in a write while holding the send lock.
+	session.sendLock <- struct{}{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := session.SendAudioContext(ctx, []byte{0x01}, true); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if _, err := session.InterruptContext(ctx); err == nil || !strings.Contains(err.Error(), "no request to interrupt") {
+		t.Fatalf("expected interrupt without request to fail, got %v", err)
+	}
+
+	session.releaseSend()
+
+	reqID, err := session.SendAudioContext(context.Background(), []byte{0x01}, true)
+	if err != nil {
+		t.Fatalf("SendAudioContext returned error: %v", err)
+	}
+	if got := serverConn.next(t).GetClientAudioInput().GetReqId(); got != reqID {
+		t.Fatalf("expected audio for %q, got %q", reqID, got)
+	}
+
+	interrupted, err := session.InterruptContext(context.Background())
+	if err != nil {
+		t.Fatalf("InterruptContext returned error: %v", err)
+	}
+	if got := serverConn.next(t).GetClientInterrupt().GetReqId(); got != interrupted || got != reqID {
+		t.Fatalf("expected interrupt for %q, got %q", reqID, got)
+	}
+}
+
+func TestAvatarSessionSendAudioContextFailsEncodedRequest(t *testing.T) {
+	clientConn, _ := dialTestWebSocketPair(t)
+	session := NewAvatarSession(
+		WithAudioFormat(AudioFormatOggOpus),
+		WithSampleRate(24000),
+		WithOggOpusEncoder(nil),
+	)
+	attachTestConn(session, clientConn)
+	defer session.Close() // nolint:errcheck
+	stallConnWriter(t, session.writer)
+
+	// The chunk is encoded, including the stream headers, but never leaves the queue.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := session.SendAudioContext(ctx, bytes.Repeat([]byte{0x00, 0x00}, 480), false); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	session.mu.Lock()
+	current, inFlight := session.current, len(session.requests)
+	session.mu.Unlock()
+	if current != nil || inFlight != 0 {
+		t.Fatalf("expected the broken request to be finished, current=%v in flight=%d", current, inFlight)
+	}
+	if stats := session.Stats(); stats.RequestsFailed != 1 {
+		t.Fatalf("expected the request to count as failed, got %+v", stats)
+	}
+}
+
+func TestAvatarSessionOutboundQueueWithInternalEncoder(t *testing.T) {
+	ingress := newFakeIngress(t)
+	session := NewAvatarSession(
+		WithAvatarID("avatar-123"),
+		WithAppID("app-123"),
+		WithIngressEndpointURL(ingress.url()),
+		WithAudioFormat(AudioFormatOggOpus),
+		WithOggOpusEncoder(nil),
+		WithOutboundQueue(8, OutboundQueueDropOldestAudio),
+	)
+	session.sessionToken = "session-token-123"
+	if _, err := session.Start(context.Background()); err == nil || !strings.Contains(err.Error(), "drop_oldest_audio") {
+		t.Fatalf("expected Start to reject drop_oldest_audio with the internal encoder, got %v", err)
+	}
+
+	clientConn, _ := dialTestWebSocketPair(t)
+	session = NewAvatarSession(
+		WithAudioFormat(AudioFormatOggOpus),
+		WithSampleRate(24000),
+		WithOggOpusEncoder(nil),
+		WithOutboundQueue(1, OutboundQueueFailFast),
+	)
+	attachTestConn(session, clientConn)
+	defer session.Close() // nolint:errcheck
+	stallConnWriter(t, session.writer)
+
+	// The rejected chunk was already encoded, so its request cannot continue.
+	chunk := bytes.Repeat([]byte{0x00, 0x00}, 480)
+	if _, err := session.SendAudio(chunk, false); err != nil {
+		t.Fatalf("SendAudio returned error: %v", err)
+	}
+	if _, err := session.SendAudio(chunk, false); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+	session.mu.Lock()
+	current, inFlight := session.current, len(session.requests)
+	session.mu.Unlock()
+	if current != nil || inFlight != 0 {
+		t.Fatalf("expected the broken request to be finished, current=%v in flight=%d", current, inFlight)
+	}
+	if stats := session.Stats(); stats.RequestsFailed != 1 {
+		t.Fatalf("expected the request to count as failed, got %+v", stats)
+	}
+}
+
+func TestAvatarSessionOutboundQueue(t *testing.T) {
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress, WithOutboundQueue(8, OutboundQueueFailFast))
+	defer session.Close() // nolint:errcheck
+	serverConn := ingress.accept(t)
+
+	var reqID string
+	for i := 0; i < 3; i++ {
+		id, err := session.SendAudio([]byte{byte(i)}, i == 2)
+		if err != nil {
+			t.Fatalf("SendAudio returned error: %v", err)
+		}
+		reqID = id
+	}
+	for i := 0; i < 3; i++ {
+		audio := serverConn.next(t).GetClientAudioInput()
+		if audio.GetReqId() != reqID {
+			t.Fatalf("expected audio for %q, got %q", reqID, audio.GetReqId())
+		}
+		if audio.GetEnd() != (i == 2) {
+			t.Fatalf("chunk %d: unexpected end flag %v", i, audio.GetEnd())
+		}
+	}
+
+	if stats := session.OutboundQueueStats(); stats.Capacity != 8 || stats.Rejected != 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestAvatarSessionUsesHTTPClient(t *testing.T) {
+	console, _ := newTokenConsole(t, func(int32) int { return http.StatusOK })
+	consoleURL, err := url.Parse(console.URL)
+	if err != nil {
+		t.Fatalf("failed to parse console URL: %v", err)
+	}
+
+	var routed atomic.Int32
+	client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		routed.Add(1)
+		req.URL.Host = consoleURL.Host
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+
+	session := NewAvatarSession(
+		WithAPIKey("api-key"),
+		WithExpireAt(time.Now().Add(time.Hour)),
+		WithConsoleEndpointURL("http://console.invalid"),
+		WithHTTPClient(client),
+	)
+	if err := session.Init(context.Background()); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	if routed.Load() != 1 {
+		t.Fatalf("expected the token request to use the custom client, got %d requests", routed.Load())
+	}
+}
+
+func TestAvatarSessionUsesWebSocketDialer(t *testing.T) {
+	ingress := newFakeIngress(t)
+	ingressAddr := ingress.server.Listener.Addr().String()
+
+	var dials atomic.Int32
+	dialer := &websocket.Dialer{
+		NetDialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			dials.Add(1)
+			var d net.Dialer
+			return d.DialContext(ctx, network, ingressAddr)
+		},
+	}
+	reconnected := make(chan struct{})
+	session := NewAvatarSession(
+		WithAvatarID("avatar-123"),
+		WithAppID("app-123"),
+		WithIngressEndpointURL("ws://ingress.invalid"),
+		WithWebSocketDialer(dialer),
+		WithReconnect(ReconnectPolicy{InitialBackoff: 10 * time.Millisecond}),
+		WithOnReconnect(func(ReconnectEvent) { close(reconnected) }),
+	)
+	defer session.Close() // nolint:errcheck
+	session.sessionToken = "session-token-123"
+	session.state = SessionStateInitialized
+
+	if _, err := session.Start(context.Background()); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	first := ingress.accept(t)
+	_ = first.conn.Close()
+
+	select {
+	case <-reconnected:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for reconnect")
+	}
+	if dials.Load() != 2 {
+		t.Fatalf("expected Start and reconnect to use the custom dialer, got %d dials", dials.Load())
+	}
+}
+
+func TestAvatarSessionStartHandshakeOversizedMessage(t *testing.T) {
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool { return true },
+	}
+
+	closeCodes := make(chan int, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// Read ClientConfigureSession
+		_, _, _ = conn.ReadMessage()
+
+		_ = conn.WriteMessage(websocket.BinaryMessage, make([]byte, 4096))
+		_, _, err = conn.ReadMessage()
+		var closeErr *websocket.CloseError
+		if errors.As(err, &closeErr) {
+			closeCodes <- closeErr.Code
+		}
+	}))
+	defer server.Close()
+
+	session := NewAvatarSession(
+		WithAvatarID("avatar-123"),
+		WithAppID("app-123"),
+		WithIngressEndpointURL(strings.Replace(server.URL, "http", "ws", 1)),
+		WithMaxMessageSize(1024),
+	)
+	session.sessionToken = "token"
+
+	_, err := session.Start(context.Background())
+	var sdkErr *AvatarSDKError
+	if !errors.As(err, &sdkErr) || sdkErr.Code != ErrorCodeProtocolError {
+		t.Fatalf("expected protocol error, got %v", err)
+	}
+	if !strings.Contains(sdkErr.Message, "at least 1025 bytes") || !strings.Contains(sdkErr.Message, "1024 byte limit") {
+		t.Fatalf("expected the message size and limit in the error, got %q", sdkErr.Message)
+	}
+	var tooLarge *MessageTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.Size != 1025 || tooLarge.Limit != 1024 || !tooLarge.Incomplete {
+		t.Fatalf("expected a MessageTooLargeError for at least 1025/1024 bytes, got %#v", tooLarge)
+	}
+	select {
+	case code := <-closeCodes:
+		if code != websocket.CloseMessageTooBig {
+			t.Fatalf("expected close code %d, got %d", websocket.CloseMessageTooBig, code)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the close frame")
+	}
+}
+
+func TestReadLoopOversizedMessage(t *testing.T) {
+	errs := make(chan error, 4)
+	frames := make(chan AnimationFrame, 1)
+	closed := make(chan struct{})
+
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress,
+		WithMaxMessageSize(1024),
+		WithOnError(func(err error) { errs <- err }),
+		WithOnAnimationFrame(func(frame AnimationFrame) { frames <- frame }),
+		WithOnClose(func() { close(closed) }),
+	)
+	defer session.Close() // nolint:errcheck
+	serverConn := ingress.accept(t)
+
+	// Messages within the limit are delivered as usual.
+	serverConn.send(t, animationMessage(serverConn.connectionID, "req-1", true))
+	select {
+	case <-frames:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for frame within the limit")
+	}
+
+	if err := serverConn.conn.WriteMessage(websocket.BinaryMessage, make([]byte, 64*1024)); err != nil {
+		t.Fatalf("failed to write oversized message: %v", err)
+	}
+	select {
+	case err := <-errs:
+		var sdkErr *AvatarSDKError
+		if !errors.As(err, &sdkErr) || sdkErr.Code != ErrorCodeProtocolError {
+			t.Fatalf("expected protocol error, got %v", err)
+		}
+		if !strings.Contains(sdkErr.Message, "at least 1025 bytes") {
+			t.Fatalf("expected the message size in the error, got %q", sdkErr.Message)
+		}
+		var tooLarge *MessageTooLargeError
+		if !errors.As(err, &tooLarge) || tooLarge.Size != 1025 || tooLarge.Limit != 1024 || !tooLarge.Incomplete {
+			t.Fatalf("expected a MessageTooLargeError for at least 1025/1024 bytes, got %#v", tooLarge)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for oversized message error")
+	}
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the session to close after an oversized message")
+	}
+}
+
+func TestReadLoopEndlessOversizedMessage(t *testing.T) {
+	errs := make(chan error, 4)
+	closed := make(chan struct{})
+
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress,
+		WithMaxMessageSize(1024),
+		WithOnError(func(err error) { errs <- err }),
+		WithOnClose(func() { close(closed) }),
+	)
+	defer session.Close() // nolint:errcheck
+	serverConn := ingress.accept(t)
+
+	// Start a fragmented message and never finish it.
+	w, err := serverConn.conn.NextWriter(websocket.BinaryMessage)
+	if err != nil {
+		t.Fatalf("NextWriter returned error: %v", err)
+	}
+	if _, err := w.Write(make([]byte, 16*1024)); err != nil {
+		t.Fatalf("failed to write message fragments: %v", err)
+	}
+
+	select {
+	case err := <-errs:
+		var tooLarge *MessageTooLargeError
+		if !errors.As(err, &tooLarge) || !tooLarge.Incomplete {
+			t.Fatalf("expected an incomplete MessageTooLargeError, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for oversized message error")
+	}
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the session to close after an oversized message")
+	}
+}
+
+func TestAvatarSessionBufferSizes(t *testing.T) {
+	dialer := &websocket.Dialer{ReadBufferSize: 512, HandshakeTimeout: time.Second}
+	session := NewAvatarSession(
+		WithWebSocketDialer(dialer),
+		WithReadBufferSize(64*1024),
+		WithWriteBufferSize(32*1024),
+	)
+
+	got := session.webSocketDialer()
+	if got.ReadBufferSize != 64*1024 || got.WriteBufferSize != 32*1024 {
+		t.Fatalf("expected buffer sizes 65536/32768, got %d/%d", got.ReadBufferSize, got.WriteBufferSize)
+	}
+	if got.HandshakeTimeout != time.Second {
+		t.Fatal("expected the other dialer settings to be kept")
+	}
+	if dialer.ReadBufferSize != 512 || dialer.WriteBufferSize != 0 {
+		t.Fatal("expected the caller's dialer not to be modified")
+	}
+	if NewAvatarSession().webSocketDialer() != websocket.DefaultDialer {
+		t.Fatal("expected the default dialer without buffer options")
+	}
+}
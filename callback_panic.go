@@ -0,0 +1,47 @@
+package avatarsdkgo
+
+import (
+	"errors"
+	"runtime/debug"
+)
+
+// errCallbackPanicked is returned in place of the result of user code that panicked.
+var errCallbackPanicked = errors.New("callback panicked")
+
+// CallbackPanic describes a panic recovered from a user callback.
+type CallbackPanic struct {
+	// Callback names the option that registered the callback, such as "OnAnimationFrame", or
+	// the interface method for MetricsRecorder, Tracer, Span and TokenSource implementations,
+	// such as "MetricsRecorder.FrameReceived".
+	Callback string
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the goroutine stack at the time of the panic.
+	Stack []byte
+}
+
+// invokeCallback runs the user callback fn registered as name and recovers from any panic
+// in it, so a faulty handler can neither crash the process nor stop the goroutine delivering
+// callbacks.
+func (s *AvatarSession) invokeCallback(name string, fn func()) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			s.reportCallbackPanic(CallbackPanic{Callback: name, Value: recovered, Stack: debug.Stack()})
+		}
+	}()
+	fn()
+}
+
+// reportCallbackPanic hands p to OnCallbackPanic, or logs it when no handler is configured.
+func (s *AvatarSession) reportCallbackPanic(p CallbackPanic) {
+	if s.config != nil && s.config.OnCallbackPanic != nil {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				s.fallbackLogger().Warn("callback panic handler panicked", "callback", p.Callback, "panic", recovered)
+			}
+		}()
+		s.config.OnCallbackPanic(p)
+		return
+	}
+	s.fallbackLogger().Warn("callback panicked", "callback", p.Callback, "panic", p.Value, "stack", string(p.Stack))
+}
@@ -0,0 +1,300 @@
+package avatarsdkgo
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	message "github.com/spatialwalk/avatar-sdk-go/proto/generated"
+)
+
+func TestPanickingFrameCallbacksDoNotStallReadLoop(t *testing.T) {
+	panics := &panicRecorder{}
+	frames := make(chan AnimationFrame, 3)
+
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress,
+		WithTransportFrames(func([]byte, bool) { panic("transport frames failed") }),
+		WithOnAnimationFrame(func(frame AnimationFrame) {
+			frames <- frame
+			if !frame.End {
+				panic("animation frame failed")
+			}
+		}),
+		WithOnCallbackPanic(panics.record),
+	)
+	defer session.Close() // nolint:errcheck
+	serverConn := ingress.accept(t)
+
+	req, err := session.BeginRequest()
+	if err != nil {
+		t.Fatalf("BeginRequest returned error: %v", err)
+	}
+	reqFrames := req.Frames()
+	serverConn.send(t, animationMessage(serverConn.connectionID, req.ID(), false))
+	serverConn.send(t, animationMessage(serverConn.connectionID, req.ID(), false))
+	serverConn.send(t, animationMessage(serverConn.connectionID, req.ID(), true))
+
+	// Every frame still reaches the next callback and the request handle.
+	for i := 0; i < 3; i++ {
+		select {
+		case <-frames:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for frame %d", i)
+		}
+	}
+	assertRequestFrames(t, req, reqFrames, []bool{false, false, true})
+
+	got := panics.wait(t, 5)
+	counts := map[string]int{}
+	for _, p := range got {
+		counts[p.Callback]++
+		if !strings.Contains(string(p.Stack), "callback_panic_test.go") {
+			t.Fatalf("expected stack to include the panicking handler, got:\n%s", p.Stack)
+		}
+	}
+	if counts["TransportFrames"] != 3 || counts["OnAnimationFrame"] != 2 {
+		t.Fatalf("unexpected panics per callback %v", counts)
+	}
+}
+
+func TestPanickingLifecycleCallbacksAreRecovered(t *testing.T) {
+	panics := &panicRecorder{}
+
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress,
+		WithOnError(func(error) { panic("on error failed") }),
+		WithOnClose(func() { panic("on close failed") }),
+		WithOnStateChange(func(SessionState, SessionState) { panic("on state change failed") }),
+		WithOnCallbackPanic(panics.record),
+	)
+	events := session.Events()
+	serverConn := ingress.accept(t)
+
+	serverConn.send(t, &message.Message{
+		Type: message.MessageType_MESSAGE_SERVER_ERROR,
+		Data: &message.Message_ServerError{
+			ServerError: &message.ServerError{
+				ConnectionId: serverConn.connectionID,
+				Code:         500,
+				Message:      "boom",
+			},
+		},
+	})
+	panics.waitFor(t, "OnError")
+	if err := session.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	// The events channel is still closed after the panicking OnClose.
+	deadline := time.After(2 * time.Second)
+	for open := true; open; {
+		select {
+		case _, open = <-events:
+		case <-deadline:
+			t.Fatal("timed out waiting for the events channel to close")
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, p := range panics.snapshot() {
+		seen[p.Callback] = true
+	}
+	for _, name := range []string{"OnError", "OnClose", "OnStateChange"} {
+		if !seen[name] {
+			t.Fatalf("expected a recovered panic from %s, got %v", name, seen)
+		}
+	}
+}
+
+func TestCallbackPanicIsLoggedWithoutHandler(t *testing.T) {
+	sink := &logBuffer{}
+	logger := slog.New(slog.NewJSONHandler(sink, nil))
+	closed := make(chan struct{})
+
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress,
+		WithLogger(logger),
+		WithOnAnimationFrame(func(AnimationFrame) { panic("animation frame failed") }),
+		WithOnClose(func() { close(closed) }),
+	)
+	serverConn := ingress.accept(t)
+	serverConn.send(t, animationMessage(serverConn.connectionID, "req-1", true))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(sink.String(), "callback panicked") {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the panic to be logged")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := session.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	<-closed
+
+	for _, record := range sink.records(t) {
+		if record["msg"] != "callback panicked" {
+			continue
+		}
+		if record["callback"] != "OnAnimationFrame" || record["panic"] != "animation frame failed" {
+			t.Fatalf("unexpected panic record %v", record)
+		}
+		if stack, _ := record["stack"].(string); !strings.Contains(stack, "callback_panic_test.go") {
+			t.Fatalf("expected the stack in the panic record, got %v", record["stack"])
+		}
+		return
+	}
+	t.Fatal("expected a callback panicked record")
+}
+
+func TestPanickingMetricsRecorderIsRecovered(t *testing.T) {
+	panics := &panicRecorder{}
+	frames := make(chan AnimationFrame, 2)
+
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress,
+		WithMetricsRecorder(panickingMetrics{}),
+		WithOnAnimationFrame(func(frame AnimationFrame) { frames <- frame }),
+		WithOnCallbackPanic(panics.record),
+	)
+	defer session.Close() // nolint:errcheck
+	serverConn := ingress.accept(t)
+
+	reqID, err := session.SendAudio([]byte{0x01, 0x02}, true)
+	if err != nil {
+		t.Fatalf("SendAudio returned error: %v", err)
+	}
+	serverConn.next(t)
+	serverConn.send(t, animationMessage(serverConn.connectionID, reqID, false))
+	serverConn.send(t, animationMessage(serverConn.connectionID, reqID, true))
+
+	// The read loop survives the panicking recorder and keeps delivering frames.
+	for i := 0; i < 2; i++ {
+		select {
+		case <-frames:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for frame %d", i)
+		}
+	}
+	for _, name := range []string{
+		"MetricsRecorder.RequestStarted",
+		"MetricsRecorder.AudioSent",
+		"MetricsRecorder.FrameReceived",
+		"MetricsRecorder.RequestFinished",
+	} {
+		panics.waitFor(t, name)
+	}
+}
+
+func TestPanickingTracerAndTokenSourceAreRecovered(t *testing.T) {
+	panics := &panicRecorder{}
+	var calls atomic.Int32
+	source := tokenSourceFunc(func(context.Context) (string, time.Time, error) {
+		if calls.Add(1) == 1 {
+			panic("token source failed")
+		}
+		return "source-token", time.Time{}, nil
+	})
+
+	ingress := newFakeIngress(t)
+	session := NewAvatarSession(
+		WithTokenSource(source),
+		WithTracer(panickingTracer{}),
+		WithAvatarID("avatar-123"),
+		WithAppID("app-123"),
+		WithIngressEndpointURL(ingress.url()),
+		WithOnCallbackPanic(panics.record),
+	)
+	defer session.Close() // nolint:errcheck
+
+	if err := session.Init(context.Background()); !errors.Is(err, errCallbackPanicked) {
+		t.Fatalf("expected Init to fail after the token source panicked, got %v", err)
+	}
+	if err := session.Init(context.Background()); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	if _, err := session.Start(context.Background()); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	ingress.accept(t)
+
+	for _, name := range []string{"TokenSource.Token", "Tracer.StartSpan", "Span.SetAttributes", "Span.End"} {
+		panics.waitFor(t, name)
+	}
+}
+
+// panickingMetrics is a MetricsRecorder whose every method panics.
+type panickingMetrics struct{}
+
+func (panickingMetrics) RequestStarted(string, time.Time)         { panic("request started failed") }
+func (panickingMetrics) AudioSent(string, int, bool, time.Time)   { panic("audio sent failed") }
+func (panickingMetrics) FrameReceived(AnimationFrame)             { panic("frame received failed") }
+func (panickingMetrics) RequestFinished(string, error, time.Time) { panic("request finished failed") }
+
+// panickingTracer panics when the dial span starts and hands out panicking spans otherwise.
+type panickingTracer struct{}
+
+func (panickingTracer) StartSpan(ctx context.Context, name string, _ ...SpanAttribute) (context.Context, Span) {
+	if name == SpanWebSocketDial {
+		panic("start span failed")
+	}
+	return ctx, panickingSpan{}
+}
+
+type panickingSpan struct{}
+
+func (panickingSpan) SetAttributes(...SpanAttribute) { panic("set attributes failed") }
+func (panickingSpan) End(error)                      { panic("end failed") }
+
+type panicRecorder struct {
+	mu     sync.Mutex
+	panics []CallbackPanic
+}
+
+func (r *panicRecorder) record(p CallbackPanic) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.panics = append(r.panics, p)
+}
+
+func (r *panicRecorder) snapshot() []CallbackPanic {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]CallbackPanic(nil), r.panics...)
+}
+
+func (r *panicRecorder) wait(t *testing.T, n int) []CallbackPanic {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if got := r.snapshot(); len(got) >= n {
+			return got
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d recovered panics, got %d", n, len(r.snapshot()))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func (r *panicRecorder) waitFor(t *testing.T, callback string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		for _, p := range r.snapshot() {
+			if p.Callback == callback {
+				return
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for a recovered panic from %s", callback)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,308 @@
+package avatarsdkgo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+var (
+	// ErrQueueFull is returned by the OutboundQueueFailFast policy when the outbound queue is full.
+	ErrQueueFull = errors.New("outbound queue full")
+
+	// errWriterClosed is reported for frames that were still queued when the connection was closed.
+	errWriterClosed = errors.New("websocket writer closed")
+	// errFrameDropped is reported for audio frames discarded by OutboundQueueDropOldestAudio.
+	errFrameDropped = errors.New("outbound frame dropped")
+)
+
+// OutboundQueueStats is a snapshot of the outbound message queue for the current connection.
+type OutboundQueueStats struct {
+	// Capacity is the configured queue size, or zero when the queue is disabled.
+	Capacity int
+	// Depth is the number of messages waiting to be written.
+	Depth int
+	// Bytes is the total payload size of the messages waiting to be written.
+	Bytes int
+	// DroppedMessages counts audio messages discarded by OutboundQueueDropOldestAudio.
+	DroppedMessages uint64
+	// DroppedBytes is the payload size of the dropped messages.
+	DroppedBytes uint64
+	// Rejected counts messages refused with ErrQueueFull.
+	Rejected uint64
+}
+
+// outboundFrame is a single websocket data message waiting to be written.
+type outboundFrame struct {
+	messageType int
+	data        []byte
+	deadline    time.Time // zero means no per-message deadline
+	droppable   bool      // audio that OutboundQueueDropOldestAudio may discard
+	result      chan error
+}
+
+// connWriterOptions configures a connWriter.
+type connWriterOptions struct {
+	writeTimeout time.Duration // applied to every write in addition to the frame deadline
+	queueSize    int           // bound for post; zero leaves the queue unbounded
+	queuePolicy  OutboundQueuePolicy
+}
+
+// connWriter owns every data-frame write on a websocket connection.
+// Gorilla websocket allows only one concurrent writer, so all outbound messages
+// are queued here and written in FIFO order by a single goroutine.
+//
+// A failed write leaves the websocket unusable, so the writer then closes the
+// connection; the read loop observes the failure and runs the usual drop handling.
+type connWriter struct {
+	conn *websocket.Conn
+	opts connWriterOptions
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	queue    []*outboundFrame
+	bytes    int
+	space    chan struct{} // closed and replaced whenever a frame leaves the queue
+	closed   bool
+	err      error // reported for writes after the writer stopped
+	dropped  uint64
+	dropSize uint64
+	rejected uint64
+
+	done chan struct{}
+}
+
+func newConnWriter(conn *websocket.Conn, opts connWriterOptions) *connWriter {
+	if opts.queueSize < 0 {
+		opts.queueSize = 0
+	}
+	w := &connWriter{
+		conn:  conn,
+		opts:  opts,
+		space: make(chan struct{}),
+		err:   errWriterClosed,
+		done:  make(chan struct{}),
+	}
+	w.cond = sync.NewCond(&w.mu)
+	go w.run()
+	return w
+}
+
+// enqueue appends a message to the write queue regardless of the queue bound.
+// The frame's result channel receives the write result exactly once.
+func (w *connWriter) enqueue(messageType int, data []byte, deadline time.Time) (*outboundFrame, error) {
+	frame := newOutboundFrame(messageType, data, deadline, false)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil, w.err
+	}
+	w.pushLocked(frame)
+	return frame, nil
+}
+
+// write queues a message and blocks until it has been written to the connection
+// or ctx is done. The context deadline is applied as the write deadline. A message
+// that is still queued when ctx ends is discarded; one that is already being
+// written completes under its own deadline.
+func (w *connWriter) write(ctx context.Context, messageType int, data []byte) error {
+	deadline, _ := ctx.Deadline()
+	frame, err := w.enqueue(messageType, data, deadline)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case err := <-frame.result:
+		return err
+	case <-ctx.Done():
+		w.remove(frame)
+		return ctx.Err()
+	}
+}
+
+// post queues a message without waiting for it to be written, applying the
+// configured queue bound and policy. Only the wait for queue space honors ctx;
+// write failures surface through the connection's drop handling.
+func (w *connWriter) post(ctx context.Context, messageType int, data []byte, droppable bool) error {
+	frame := newOutboundFrame(messageType, data, time.Time{}, droppable)
+
+	w.mu.Lock()
+	for {
+		if w.closed {
+			err := w.err
+			w.mu.Unlock()
+			return err
+		}
+		if w.opts.queueSize == 0 || len(w.queue) < w.opts.queueSize {
+			break
+		}
+
+		switch w.opts.queuePolicy {
+		case OutboundQueueFailFast:
+			w.rejected++
+			w.mu.Unlock()
+			return ErrQueueFull
+		case OutboundQueueDropOldestAudio:
+			if w.dropOldestAudioLocked() {
+				continue
+			}
+		}
+
+		// Block, or nothing left to drop: wait for the writer to make room.
+		space := w.space
+		w.mu.Unlock()
+		select {
+		case <-space:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		w.mu.Lock()
+	}
+	w.pushLocked(frame)
+	w.mu.Unlock()
+	return nil
+}
+
+// stats returns a snapshot of the queue.
+func (w *connWriter) stats() OutboundQueueStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return OutboundQueueStats{
+		Capacity:        w.opts.queueSize,
+		Depth:           len(w.queue),
+		Bytes:           w.bytes,
+		DroppedMessages: w.dropped,
+		DroppedBytes:    w.dropSize,
+		Rejected:        w.rejected,
+	}
+}
+
+// remove drops frame from the queue if it has not been picked up for writing yet.
+func (w *connWriter) remove(frame *outboundFrame) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i, queued := range w.queue {
+		if queued == frame {
+			w.removeAtLocked(i)
+			return
+		}
+	}
+}
+
+// close stops the writer. Frames that have not been written yet fail with errWriterClosed.
+func (w *connWriter) close() {
+	w.mu.Lock()
+	w.closed = true
+	w.cond.Broadcast()
+	w.signalSpaceLocked()
+	w.mu.Unlock()
+}
+
+func newOutboundFrame(messageType int, data []byte, deadline time.Time, droppable bool) *outboundFrame {
+	return &outboundFrame{
+		messageType: messageType,
+		data:        data,
+		deadline:    deadline,
+		droppable:   droppable,
+		result:      make(chan error, 1),
+	}
+}
+
+// pushLocked appends frame to the queue. Callers must hold w.mu.
+func (w *connWriter) pushLocked(frame *outboundFrame) {
+	w.queue = append(w.queue, frame)
+	w.bytes += len(frame.data)
+	w.cond.Signal()
+}
+
+// removeAtLocked removes the frame at index i. Callers must hold w.mu.
+func (w *connWriter) removeAtLocked(i int) *outboundFrame {
+	frame := w.queue[i]
+	if i == 0 {
+		w.queue[0] = nil
+		w.queue = w.queue[1:]
+	} else {
+		copy(w.queue[i:], w.queue[i+1:])
+		w.queue[len(w.queue)-1] = nil
+		w.queue = w.queue[:len(w.queue)-1]
+	}
+	w.bytes -= len(frame.data)
+	w.signalSpaceLocked()
+	return frame
+}
+
+// dropOldestAudioLocked discards the oldest droppable frame and reports whether one was found.
+// Callers must hold w.mu.
+func (w *connWriter) dropOldestAudioLocked() bool {
+	for i, frame := range w.queue {
+		if frame.droppable {
+			w.removeAtLocked(i)
+			w.dropped++
+			w.dropSize += uint64(len(frame.data))
+			frame.result <- errFrameDropped
+			return true
+		}
+	}
+	return false
+}
+
+// signalSpaceLocked wakes every post waiting for queue space. Callers must hold w.mu.
+func (w *connWriter) signalSpaceLocked() {
+	close(w.space)
+	w.space = make(chan struct{})
+}
+
+// writeDeadline combines the frame deadline with the writer's timeout.
+func (w *connWriter) writeDeadline(frame *outboundFrame) time.Time {
+	deadline := frame.deadline
+	if w.opts.writeTimeout > 0 {
+		if timeout := time.Now().Add(w.opts.writeTimeout); deadline.IsZero() || timeout.Before(deadline) {
+			deadline = timeout
+		}
+	}
+	return deadline
+}
+
+func (w *connWriter) run() {
+	defer close(w.done)
+
+	for {
+		w.mu.Lock()
+		for len(w.queue) == 0 && !w.closed {
+			w.cond.Wait()
+		}
+		if w.closed {
+			pending := w.queue
+			w.queue = nil
+			w.bytes = 0
+			err := w.err
+			w.mu.Unlock()
+			for _, frame := range pending {
+				frame.result <- err
+			}
+			return
+		}
+		frame := w.removeAtLocked(0)
+		w.mu.Unlock()
+
+		err := w.conn.SetWriteDeadline(w.writeDeadline(frame))
+		if err == nil {
+			err = w.conn.WriteMessage(frame.messageType, frame.data)
+		}
+		frame.result <- err
+
+		if err != nil {
+			w.mu.Lock()
+			w.closed = true
+			w.err = err
+			w.signalSpaceLocked()
+			w.mu.Unlock()
+			_ = w.conn.Close()
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package avatarsdkgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestConnWriterPreservesOrder(t *testing.T) {
+	clientConn, serverConn := dialTestWebSocketPair(t)
+
+	writer := newConnWriter(clientConn, connWriterOptions{})
+	defer writer.close()
+
+	frames := make([]*outboundFrame, 0, 50)
+	for i := 0; i < 50; i++ {
+		frame, err := writer.enqueue(websocket.BinaryMessage, []byte(fmt.Sprintf("frame-%d", i)), time.Time{})
+		if err != nil {
+			t.Fatalf("enqueue returned error: %v", err)
+		}
+		frames = append(frames, frame)
+	}
+
+	for i := 0; i < 50; i++ {
+		_, payload, err := serverConn.ReadMessage()
+		if err != nil {
+			t.Fatalf("server read failed: %v", err)
+		}
+		if want := fmt.Sprintf("frame-%d", i); string(payload) != want {
+			t.Fatalf("expected %q, got %q", want, payload)
+		}
+	}
+	for _, frame := range frames {
+		if err := <-frame.result; err != nil {
+			t.Fatalf("write returned error: %v", err)
+		}
+	}
+}
+
+func TestConnWriterRejectsWritesAfterClose(t *testing.T) {
+	clientConn, _ := dialTestWebSocketPair(t)
+
+	writer := newConnWriter(clientConn, connWriterOptions{})
+	writer.close()
+
+	select {
+	case <-writer.done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for writer to stop")
+	}
+
+	if err := writer.write(context.Background(), websocket.BinaryMessage, []byte("late")); !errors.Is(err, errWriterClosed) {
+		t.Fatalf("expected errWriterClosed, got %v", err)
+	}
+}
+
+func TestConnWriterDeadlineFailsStalledConnection(t *testing.T) {
+	// The server end never reads, so the client's send buffer eventually fills up.
+	clientConn, _ := dialTestWebSocketPair(t)
+
+	writer := newConnWriter(clientConn, connWriterOptions{writeTimeout: 50 * time.Millisecond})
+	defer writer.close()
+
+	chunk := make([]byte, 1<<20)
+	var writeErr error
+	for i := 0; i < 256 && writeErr == nil; i++ {
+		writeErr = writer.write(context.Background(), websocket.BinaryMessage, chunk)
+	}
+
+	var netErr net.Error
+	if !errors.As(writeErr, &netErr) || !netErr.Timeout() {
+		t.Fatalf("expected write timeout, got %v", writeErr)
+	}
+	if err := writer.write(context.Background(), websocket.BinaryMessage, []byte("late")); !errors.Is(err, writeErr) {
+		t.Fatalf("expected later writes to fail with %v, got %v", writeErr, err)
+	}
+}
+
+func TestConnWriterContextDiscardsQueuedFrame(t *testing.T) {
+	clientConn, _ := dialTestWebSocketPair(t)
+
+	writer := newConnWriter(clientConn, connWriterOptions{})
+	defer writer.close()
+
+	// Stall the writer goroutine on a message the server never reads.
+	if _, err := writer.enqueue(websocket.BinaryMessage, make([]byte, 64<<20), time.Time{}); err != nil {
+		t.Fatalf("enqueue returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := writer.write(ctx, websocket.BinaryMessage, []byte("queued")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	writer.mu.Lock()
+	queued := len(writer.queue)
+	writer.mu.Unlock()
+	if queued != 0 {
+		t.Fatalf("expected cancelled frame to be removed from the queue, %d frames left", queued)
+	}
+}
+
+func TestConnWriterWriteDeadline(t *testing.T) {
+	writer := &connWriter{opts: connWriterOptions{writeTimeout: time.Second}}
+
+	before := time.Now()
+	deadline := writer.writeDeadline(&outboundFrame{})
+	if deadline.Before(before.Add(time.Second)) || deadline.After(time.Now().Add(time.Second)) {
+		t.Fatalf("expected deadline one second from now, got %v", deadline.Sub(before))
+	}
+
+	frameDeadline := time.Now().Add(10 * time.Millisecond)
+	if got := writer.writeDeadline(&outboundFrame{deadline: frameDeadline}); !got.Equal(frameDeadline) {
+		t.Fatalf("expected the sooner frame deadline %v, got %v", frameDeadline, got)
+	}
+
+	if got := (&connWriter{}).writeDeadline(&outboundFrame{}); !got.IsZero() {
+		t.Fatalf("expected no deadline without a timeout, got %v", got)
+	}
+}
+
+func TestConnWriterPostFailFast(t *testing.T) {
+	clientConn, _ := dialTestWebSocketPair(t)
+
+	writer := newConnWriter(clientConn, connWriterOptions{queueSize: 2, queuePolicy: OutboundQueueFailFast})
+	defer writer.close()
+	stallConnWriter(t, writer)
+
+	for i := 0; i < 2; i++ {
+		if err := writer.post(context.Background(), websocket.BinaryMessage, []byte("chunk"), true); err != nil {
+			t.Fatalf("post %d returned error: %v", i, err)
+		}
+	}
+	if err := writer.post(context.Background(), websocket.BinaryMessage, []byte("chunk"), true); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+
+	stats := writer.stats()
+	if stats.Capacity != 2 || stats.Depth != 2 || stats.Bytes != 10 || stats.Rejected != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestConnWriterPostDropOldestAudio(t *testing.T) {
+	clientConn, _ := dialTestWebSocketPair(t)
+
+	writer := newConnWriter(clientConn, connWriterOptions{queueSize: 3, queuePolicy: OutboundQueueDropOldestAudio})
+	defer writer.close()
+	stallConnWriter(t, writer)
+
+	posts := []struct {
+		data      string
+		droppable bool
+	}{
+		{"interrupt", false},
+		{"audio-1", true},
+		{"audio-2", true},
+		{"audio-3", true},
+		{"end", false},
+	}
+	for _, p := range posts {
+		if err := writer.post(context.Background(), websocket.BinaryMessage, []byte(p.data), p.droppable); err != nil {
+			t.Fatalf("post %q returned error: %v", p.data, err)
+		}
+	}
+
+	writer.mu.Lock()
+	queued := make([]string, 0, len(writer.queue))
+	for _, frame := range writer.queue {
+		queued = append(queued, string(frame.data))
+	}
+	writer.mu.Unlock()
+	if got, want := strings.Join(queued, ","), "interrupt,audio-3,end"; got != want {
+		t.Fatalf("expected queue %q, got %q", want, got)
+	}
+
+	stats := writer.stats()
+	if stats.DroppedMessages != 2 || stats.DroppedBytes != 14 || stats.Depth != 3 || stats.Bytes != 19 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	// Once only non-droppable frames are left, the next post has to wait for space.
+	if err := writer.post(context.Background(), websocket.BinaryMessage, []byte("end-2"), false); err != nil {
+		t.Fatalf("post returned error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := writer.post(ctx, websocket.BinaryMessage, []byte("end-3"), false); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestConnWriterPostBlocksUntilSpace(t *testing.T) {
+	clientConn, serverConn := dialTestWebSocketPair(t)
+
+	writer := newConnWriter(clientConn, connWriterOptions{queueSize: 1, queuePolicy: OutboundQueueBlock})
+	defer writer.close()
+
+	// Queue a frame without waking the writer goroutine so the queue starts out full.
+	writer.mu.Lock()
+	writer.queue = append(writer.queue, newOutboundFrame(websocket.BinaryMessage, []byte("first"), time.Time{}, true))
+	writer.bytes += len("first")
+	writer.mu.Unlock()
+
+	posted := make(chan error, 1)
+	go func() {
+		posted <- writer.post(context.Background(), websocket.BinaryMessage, []byte("second"), true)
+	}()
+
+	writer.mu.Lock()
+	writer.cond.Signal()
+	writer.mu.Unlock()
+
+	select {
+	case err := <-posted:
+		if err != nil {
+			t.Fatalf("post returned error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for blocked post")
+	}
+
+	for _, want := range []string{"first", "second"} {
+		_, payload, err := serverConn.ReadMessage()
+		if err != nil {
+			t.Fatalf("server read failed: %v", err)
+		}
+		if string(payload) != want {
+			t.Fatalf("expected %q, got %q", want, payload)
+		}
+	}
+}
+
+// stallConnWriter parks the writer goroutine on a message the server never reads,
+// so later frames stay in the queue.
+func stallConnWriter(t *testing.T, writer *connWriter) {
+	t.Helper()
+
+	if _, err := writer.enqueue(websocket.BinaryMessage, make([]byte, 64<<20), time.Time{}); err != nil {
+		t.Fatalf("enqueue returned error: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for writer.stats().Depth != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for writer to pick up the stalling frame")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// dialTestWebSocketPair returns both ends of a websocket connection served by httptest.
+func dialTestWebSocketPair(t *testing.T) (*websocket.Conn, *websocket.Conn) {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool { return true },
+	}
+	serverConnCh := make(chan *websocket.Conn, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		serverConnCh <- conn
+	}))
+	t.Cleanup(server.Close)
+
+	clientConn, _, err := websocket.DefaultDialer.Dial(strings.Replace(server.URL, "http", "ws", 1), nil)
+	if err != nil {
+		t.Fatalf("failed to dial websocket server: %v", err)
+	}
+	serverConn := <-serverConnCh
+	t.Cleanup(func() {
+		_ = clientConn.Close()
+		_ = serverConn.Close()
+	})
+
+	return clientConn, serverConn
+}
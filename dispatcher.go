@@ -0,0 +1,145 @@
+package avatarsdkgo
+
+import "sync"
+
+// dispatcher delivers user callbacks from a single goroutine in the order they were queued.
+// A capacity of zero leaves the queue unbounded; otherwise the overflow policy decides what
+// happens when the queue is full.
+type dispatcher struct {
+	capacity int
+	overflow DispatchOverflowPolicy
+
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	queue    []dispatchEntry
+	queued   int // entries in queue that count against capacity, i.e. all but lifecycle ones
+	closed   bool
+	dropped  uint64
+
+	done chan struct{}
+}
+
+// dispatchEntry is a queued callback. Lifecycle entries are exempt from DispatchOverflowDropOldest.
+type dispatchEntry struct {
+	fn        func()
+	lifecycle bool
+}
+
+func newDispatcher(capacity int, overflow DispatchOverflowPolicy) *dispatcher {
+	if capacity < 0 {
+		capacity = 0
+	}
+	d := &dispatcher{
+		capacity: capacity,
+		overflow: overflow,
+		done:     make(chan struct{}),
+	}
+	d.notEmpty = sync.NewCond(&d.mu)
+	d.notFull = sync.NewCond(&d.mu)
+	go d.run()
+	return d
+}
+
+// dispatch queues fn for delivery. It reports false when fn was dropped, either because
+// the queue was full under a drop policy or because the dispatcher has been closed.
+func (d *dispatcher) dispatch(fn func()) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.capacity > 0 {
+		for d.queued >= d.capacity && !d.closed {
+			switch d.overflow {
+			case DispatchOverflowDropNewest:
+				d.dropped++
+				return false
+			case DispatchOverflowDropOldest:
+				d.dropOldestLocked()
+			default:
+				d.notFull.Wait()
+			}
+		}
+	}
+	if d.closed {
+		d.dropped++
+		return false
+	}
+
+	d.queue = append(d.queue, dispatchEntry{fn: fn})
+	d.queued++
+	d.notEmpty.Signal()
+	return true
+}
+
+// enqueue queues fn regardless of the capacity bound and never blocks. It is used for
+// lifecycle notifications that must not be dropped: they do not count against the capacity
+// and DispatchOverflowDropOldest never evicts them. It reports false once closed.
+func (d *dispatcher) enqueue(fn func()) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		d.dropped++
+		return false
+	}
+	d.queue = append(d.queue, dispatchEntry{fn: fn, lifecycle: true})
+	d.notEmpty.Signal()
+	return true
+}
+
+// dropOldestLocked discards the oldest callback that is not a lifecycle notification.
+// Callers must hold d.mu.
+func (d *dispatcher) dropOldestLocked() {
+	for i, entry := range d.queue {
+		if entry.lifecycle {
+			continue
+		}
+		copy(d.queue[i:], d.queue[i+1:])
+		d.queue[len(d.queue)-1] = dispatchEntry{}
+		d.queue = d.queue[:len(d.queue)-1]
+		d.queued--
+		d.dropped++
+		return
+	}
+}
+
+// close stops accepting callbacks. Callbacks already queued are still delivered.
+func (d *dispatcher) close() {
+	d.mu.Lock()
+	d.closed = true
+	d.notEmpty.Broadcast()
+	d.notFull.Broadcast()
+	d.mu.Unlock()
+}
+
+// droppedCount returns the number of callbacks discarded so far.
+func (d *dispatcher) droppedCount() uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.dropped
+}
+
+func (d *dispatcher) run() {
+	defer close(d.done)
+
+	for {
+		d.mu.Lock()
+		for len(d.queue) == 0 && !d.closed {
+			d.notEmpty.Wait()
+		}
+		if len(d.queue) == 0 {
+			d.mu.Unlock()
+			return
+		}
+		entry := d.queue[0]
+		d.queue[0] = dispatchEntry{}
+		d.queue = d.queue[1:]
+		if !entry.lifecycle {
+			d.queued--
+		}
+		d.notFull.Signal()
+		d.mu.Unlock()
+
+		entry.fn()
+	}
+}
@@ -0,0 +1,194 @@
+package avatarsdkgo
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDispatcherDeliversInOrder(t *testing.T) {
+	d := newDispatcher(0, DispatchOverflowBlock)
+
+	var got []int
+	for i := 0; i < 500; i++ {
+		d.dispatch(func() { got = append(got, i) })
+	}
+	d.close()
+	waitDispatcherDone(t, d)
+
+	if len(got) != 500 {
+		t.Fatalf("expected 500 callbacks, got %d", len(got))
+	}
+	for i, v := range got {
+		if v != i {
+			t.Fatalf("expected callback %d at position %d, got %d", i, i, v)
+		}
+	}
+}
+
+func TestDispatcherBlockWaitsForRoom(t *testing.T) {
+	d := newDispatcher(1, DispatchOverflowBlock)
+	defer d.close()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	d.dispatch(func() {
+		close(started)
+		<-release
+	})
+	<-started
+	d.dispatch(func() {})
+
+	blocked := make(chan bool, 1)
+	go func() {
+		blocked <- d.dispatch(func() {})
+	}()
+
+	select {
+	case <-blocked:
+		t.Fatal("expected dispatch to block while the queue is full")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case ok := <-blocked:
+		if !ok {
+			t.Fatal("expected blocked callback to be queued once room was available")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for blocked dispatch")
+	}
+}
+
+func TestDispatcherDropPolicies(t *testing.T) {
+	tests := []struct {
+		policy DispatchOverflowPolicy
+		want   []int
+	}{
+		{DispatchOverflowDropNewest, []int{0, 1, 2}},
+		{DispatchOverflowDropOldest, []int{0, 3, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			d := newDispatcher(2, tt.policy)
+
+			release := make(chan struct{})
+			started := make(chan struct{})
+			var got []int
+			d.dispatch(func() {
+				got = append(got, 0)
+				close(started)
+				<-release
+			})
+			<-started
+
+			for i := 1; i <= 4; i++ {
+				d.dispatch(func() { got = append(got, i) })
+			}
+			close(release)
+			d.close()
+			waitDispatcherDone(t, d)
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected callbacks %v, got %v", tt.want, got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Fatalf("expected callbacks %v, got %v", tt.want, got)
+				}
+			}
+			if dropped := d.droppedCount(); dropped != 2 {
+				t.Fatalf("expected 2 dropped callbacks, got %d", dropped)
+			}
+		})
+	}
+}
+
+func TestDispatcherDropsAfterClose(t *testing.T) {
+	d := newDispatcher(0, DispatchOverflowBlock)
+	d.close()
+	waitDispatcherDone(t, d)
+
+	if d.dispatch(func() { t.Error("callback ran after close") }) {
+		t.Fatal("expected dispatch to report a dropped callback after close")
+	}
+}
+
+func TestDispatcherEnqueueBypassesCapacity(t *testing.T) {
+	d := newDispatcher(1, DispatchOverflowDropNewest)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	d.dispatch(func() {
+		close(started)
+		<-release
+	})
+	<-started
+	d.dispatch(func() {})
+
+	var ran []string
+	if d.dispatch(func() { ran = append(ran, "dropped") }) {
+		t.Fatal("expected dispatch to drop while the queue is full")
+	}
+	if !d.enqueue(func() { ran = append(ran, "lifecycle") }) {
+		t.Fatal("expected enqueue to accept a callback while the queue is full")
+	}
+
+	close(release)
+	d.close()
+	waitDispatcherDone(t, d)
+
+	if len(ran) != 1 || ran[0] != "lifecycle" {
+		t.Fatalf("expected only the enqueued callback to run, got %v", ran)
+	}
+	if d.enqueue(func() {}) {
+		t.Fatal("expected enqueue to fail after close")
+	}
+}
+
+func TestDispatcherDropOldestKeepsLifecycleCallbacks(t *testing.T) {
+	d := newDispatcher(2, DispatchOverflowDropOldest)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	d.dispatch(func() {
+		close(started)
+		<-release
+	})
+	<-started
+
+	var ran []string
+	d.enqueue(func() { ran = append(ran, "state") })
+	for i := 1; i <= 4; i++ {
+		d.dispatch(func() { ran = append(ran, fmt.Sprintf("frame-%d", i)) })
+	}
+	d.enqueue(func() { ran = append(ran, "reconnect") })
+	d.enqueue(func() { ran = append(ran, "close") })
+	d.dispatch(func() { ran = append(ran, "frame-5") })
+
+	close(release)
+	d.close()
+	waitDispatcherDone(t, d)
+
+	// Frames are evicted around the lifecycle callbacks, which neither count against the
+	// capacity nor get dropped.
+	want := []string{"state", "frame-4", "reconnect", "close", "frame-5"}
+	if strings.Join(ran, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected callbacks %v, got %v", want, ran)
+	}
+	if dropped := d.droppedCount(); dropped != 3 {
+		t.Fatalf("expected 3 dropped callbacks, got %d", dropped)
+	}
+}
+
+func waitDispatcherDone(t *testing.T, d *dispatcher) {
+	t.Helper()
+	select {
+	case <-d.done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for dispatcher to drain")
+	}
+}
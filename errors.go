@@ -24,6 +24,8 @@ const (
 	ErrorCodeEgressUnavailable AvatarSDKErrorCode = "egressUnavailable"
 	// ErrorCodeProtocolError indicates the websocket protocol exchange was invalid.
 	ErrorCodeProtocolError AvatarSDKErrorCode = "protocolError"
+	// ErrorCodeKeepaliveTimeout indicates the server stopped answering keepalive pings.
+	ErrorCodeKeepaliveTimeout AvatarSDKErrorCode = "keepaliveTimeout"
 	// ErrorCodeUnknown indicates an unknown error.
 	ErrorCodeUnknown AvatarSDKErrorCode = "unknown"
 )
@@ -51,6 +53,35 @@ func NewAvatarSDKError(code AvatarSDKErrorCode, message string) *AvatarSDKError
 	}
 }
 
+// MessageTooLargeError reports an inbound websocket message larger than WithMaxMessageSize.
+// It wraps an ErrorCodeProtocolError AvatarSDKError, so both are reachable with errors.As.
+type MessageTooLargeError struct {
+	// Size is the size of the discarded message in bytes, or a lower bound when Incomplete is set.
+	Size int64
+	// Limit is the configured maximum message size in bytes.
+	Limit int64
+	// Incomplete reports that the message was not read to the end, so Size is a lower bound.
+	// The session stops reading one byte past Limit, so it is set for every message it discards.
+	Incomplete bool
+}
+
+// Error implements the error interface.
+func (e *MessageTooLargeError) Error() string {
+	return e.Unwrap().Error()
+}
+
+// Unwrap returns the equivalent ErrorCodeProtocolError AvatarSDKError.
+func (e *MessageTooLargeError) Unwrap() error {
+	size := fmt.Sprintf("%d bytes", e.Size)
+	if e.Incomplete {
+		size = "at least " + size
+	}
+	return NewAvatarSDKError(
+		ErrorCodeProtocolError,
+		fmt.Sprintf("inbound message of %s exceeds the %d byte limit", size, e.Limit),
+	)
+}
+
 // mapWSConnectErrorToCode maps websocket HTTP upgrade failures to stable SDK error codes.
 // v2 spec mapping:
 // - 401 -> sessionTokenExpired
@@ -1,6 +1,7 @@
 package avatarsdkgo
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -27,6 +28,17 @@ func TestNewAvatarSDKError(t *testing.T) {
 	}
 }
 
+func TestMessageTooLargeError(t *testing.T) {
+	err := error(&MessageTooLargeError{Size: 2048, Limit: 1024, Incomplete: true})
+	if got, want := err.Error(), "protocolError: inbound message of at least 2048 bytes exceeds the 1024 byte limit"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	var sdkErr *AvatarSDKError
+	if !errors.As(err, &sdkErr) || sdkErr.Code != ErrorCodeProtocolError {
+		t.Fatalf("expected a wrapped protocol error, got %v", err)
+	}
+}
+
 func TestMapWSConnectErrorToCode(t *testing.T) {
 	tests := []struct {
 		statusCode   int
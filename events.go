@@ -0,0 +1,194 @@
+package avatarsdkgo
+
+import (
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// defaultEventBufferSize is the capacity of the Events channel when WithEventBuffer is not used.
+const defaultEventBufferSize = 64
+
+// SessionEvent is a notification delivered on the channel returned by AvatarSession.Events.
+// The set of implementations is closed: FrameEvent, ErrorEvent, StateChangeEvent,
+// EncodedAudioEvent, ReconnectEvent and CloseEvent. Use a type switch to handle them.
+type SessionEvent interface {
+	sessionEvent()
+}
+
+// FrameEvent carries an animation frame received from the server. It is emitted
+// alongside TransportFrames and OnAnimationFrame.
+type FrameEvent struct {
+	Frame AnimationFrame
+}
+
+// ErrorEvent carries an error reported by the session, the same value passed to OnError.
+// Errors sent by the server are *AvatarSDKError values.
+type ErrorEvent struct {
+	Err error
+}
+
+// StateChangeEvent reports a lifecycle state transition, like OnStateChange.
+type StateChangeEvent struct {
+	Old SessionState
+	New SessionState
+}
+
+// EncodedAudioEvent carries a completed Ogg Opus stream produced by the internal encoder,
+// like OnEncodedAudio.
+type EncodedAudioEvent struct {
+	ReqID string
+	Audio []byte
+}
+
+// CloseEvent is the last event on the channel. The channel is closed right after it.
+type CloseEvent struct{}
+
+func (FrameEvent) sessionEvent()        {}
+func (ErrorEvent) sessionEvent()        {}
+func (StateChangeEvent) sessionEvent()  {}
+func (EncodedAudioEvent) sessionEvent() {}
+func (ReconnectEvent) sessionEvent()    {}
+func (CloseEvent) sessionEvent()        {}
+
+// Events returns a channel that receives the session's notifications as SessionEvent values.
+// Every call returns the same channel. Events are delivered in the same order as the
+// callbacks, which keep working alongside the channel; only events that occur after the
+// first call are delivered. Events pass through the callback dispatcher, so a bounded
+// WithDispatchQueue that drops callbacks drops the matching events as well.
+//
+// The channel is buffered (see WithEventBuffer) and publishing never blocks the session.
+// When the buffer is full the oldest buffered event is discarded to make room, so a slow
+// reader loses old events rather than stalling frame delivery; DroppedEvents counts the
+// discarded events. After the session closes a CloseEvent is published and the channel is
+// closed. Calling Events on a closed session returns a closed channel.
+func (s *AvatarSession) Events() <-chan SessionEvent {
+	if s == nil {
+		ch := make(chan SessionEvent)
+		close(ch)
+		return ch
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.events == nil {
+		size := 0
+		if s.config != nil {
+			size = s.config.EventBufferSize
+		}
+		s.events = newEventStream(size)
+		if s.state == SessionStateClosed {
+			s.events.close()
+		}
+	}
+	return s.events.ch
+}
+
+// DroppedEvents returns the number of events discarded because the Events channel was full.
+func (s *AvatarSession) DroppedEvents() uint64 {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	events := s.events
+	s.mu.Unlock()
+	if events == nil {
+		return 0
+	}
+	return events.droppedCount()
+}
+
+// eventStream is the buffered channel behind AvatarSession.Events.
+type eventStream struct {
+	ch chan SessionEvent
+
+	mu      sync.Mutex
+	closed  bool
+	dropped uint64
+}
+
+func newEventStream(size int) *eventStream {
+	if size <= 0 {
+		size = defaultEventBufferSize
+	}
+	return &eventStream{ch: make(chan SessionEvent, size)}
+}
+
+// publish sends event without blocking, discarding the oldest buffered event when full.
+func (e *eventStream) publish(event SessionEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return
+	}
+	for {
+		select {
+		case e.ch <- event:
+			return
+		default:
+		}
+		select {
+		case <-e.ch:
+			e.dropped++
+		default:
+		}
+	}
+}
+
+// close closes the channel. Later publishes are ignored.
+func (e *eventStream) close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.closed {
+		e.closed = true
+		close(e.ch)
+	}
+}
+
+func (e *eventStream) droppedCount() uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.dropped
+}
+
+// publish sends event to the Events channel if it has been requested.
+func (s *AvatarSession) publish(event SessionEvent) {
+	s.mu.Lock()
+	events := s.events
+	s.mu.Unlock()
+	if events != nil {
+		events.publish(event)
+	}
+}
+
+// closeEvents publishes CloseEvent and closes the Events channel.
+func (s *AvatarSession) closeEvents() {
+	s.mu.Lock()
+	events := s.events
+	s.mu.Unlock()
+	if events != nil {
+		events.publish(CloseEvent{})
+		events.close()
+	}
+}
+
+// wantsEncodedAudio reports whether completed Ogg Opus streams have a consumer.
+func (s *AvatarSession) wantsEncodedAudio() bool {
+	if s.config.OnEncodedAudio != nil {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.events != nil
+}
+
+// reportErrorFrom delivers err through OnError and the Events channel on behalf of conn's
+// read loop.
+func (s *AvatarSession) reportErrorFrom(conn *websocket.Conn, err error) {
+	s.dispatchFrom(conn, func() {
+		if s.config != nil && s.config.OnError != nil {
+			s.invokeCallback("OnError", func() { s.config.OnError(err) })
+		}
+		s.publish(ErrorEvent{Err: err})
+	})
+}
@@ -0,0 +1,146 @@
+package avatarsdkgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	message "github.com/spatialwalk/avatar-sdk-go/proto/generated"
+)
+
+func TestEventsDeliversSessionEvents(t *testing.T) {
+	ingress := newFakeIngress(t)
+	callbackFrames := make(chan AnimationFrame, 1)
+	session := NewAvatarSession(
+		WithAvatarID("avatar-123"),
+		WithAppID("app-123"),
+		WithIngressEndpointURL(ingress.url()),
+		WithOnAnimationFrame(func(frame AnimationFrame) { callbackFrames <- frame }),
+	)
+	session.sessionToken = "session-token-123"
+	session.state = SessionStateInitialized
+
+	events := session.Events()
+	if again := session.Events(); again != events {
+		t.Fatal("expected Events to return the same channel on every call")
+	}
+
+	if _, err := session.Start(context.Background()); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	serverConn := ingress.accept(t)
+	serverConn.send(t, &message.Message{
+		Type: message.MessageType_MESSAGE_SERVER_RESPONSE_ANIMATION,
+		Data: &message.Message_ServerResponseAnimation{
+			ServerResponseAnimation: &message.ServerResponseAnimation{ReqId: "req-1", End: true},
+		},
+	})
+	serverConn.send(t, &message.Message{
+		Type: message.MessageType_MESSAGE_SERVER_ERROR,
+		Data: &message.Message_ServerError{
+			ServerError: &message.ServerError{ReqId: "req-2", Code: 500, Message: "boom"},
+		},
+	})
+
+	select {
+	case <-callbackFrames:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for OnAnimationFrame")
+	}
+
+	var got []string
+	deadline := time.After(2 * time.Second)
+	for len(got) < 4 {
+		select {
+		case event := <-events:
+			got = append(got, describeEvent(t, event))
+		case <-deadline:
+			t.Fatalf("timed out waiting for events, got %v", got)
+		}
+	}
+
+	if err := session.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	for event := range events {
+		got = append(got, describeEvent(t, event))
+	}
+
+	expected := []string{
+		"state initialized->connecting",
+		"state connecting->ready",
+		"frame req-1 end=true",
+		"error req-2",
+		"state ready->closed",
+		"close",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Fatalf("expected events %v, got %v", expected, got)
+	}
+}
+
+func TestEventsDropOldestWhenFull(t *testing.T) {
+	stream := newEventStream(2)
+	for i := 0; i < 5; i++ {
+		stream.publish(StateChangeEvent{Old: SessionStateIdle, New: SessionState(fmt.Sprint(i))})
+	}
+	stream.publish(CloseEvent{})
+	stream.close()
+	stream.publish(CloseEvent{})
+
+	var got []SessionEvent
+	for event := range stream.ch {
+		got = append(got, event)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 buffered events, got %v", got)
+	}
+	if change, ok := got[0].(StateChangeEvent); !ok || change.New != "4" {
+		t.Fatalf("expected the newest state change to be kept, got %#v", got[0])
+	}
+	if _, ok := got[1].(CloseEvent); !ok {
+		t.Fatalf("expected CloseEvent last, got %#v", got[1])
+	}
+	if dropped := stream.droppedCount(); dropped != 4 {
+		t.Fatalf("expected 4 dropped events, got %d", dropped)
+	}
+}
+
+func TestEventsAfterClose(t *testing.T) {
+	session := NewAvatarSession()
+	if err := session.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	select {
+	case _, ok := <-session.Events():
+		if ok {
+			t.Fatal("expected the Events channel of a closed session to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out reading from the Events channel")
+	}
+}
+
+func describeEvent(t *testing.T, event SessionEvent) string {
+	t.Helper()
+	switch e := event.(type) {
+	case StateChangeEvent:
+		return fmt.Sprintf("state %s->%s", e.Old, e.New)
+	case FrameEvent:
+		return fmt.Sprintf("frame %s end=%v", e.Frame.ReqID, e.Frame.End)
+	case ErrorEvent:
+		var sdkErr *AvatarSDKError
+		if !errors.As(e.Err, &sdkErr) {
+			t.Fatalf("expected *AvatarSDKError, got %T: %v", e.Err, e.Err)
+		}
+		return "error " + sdkErr.ReqID
+	case CloseEvent:
+		return "close"
+	default:
+		t.Fatalf("unexpected event %T", event)
+		return ""
+	}
+}
@@ -0,0 +1,299 @@
+package avatarsdkgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	message "github.com/spatialwalk/avatar-sdk-go/proto/generated"
+	"google.golang.org/protobuf/proto"
+)
+
+// defaultInterruptQuietPeriod is how long an interruption waits for late frames when
+// WithInterruptQuietPeriod is not used.
+const defaultInterruptQuietPeriod = time.Second
+
+// ErrRequestInterrupted is reported by requests that were stopped with Interrupt or InterruptRequest.
+var ErrRequestInterrupted = errors.New("request interrupted")
+
+// InterruptOutcome describes how an Interruption completed.
+type InterruptOutcome string
+
+const (
+	// InterruptOutcomeEndFrame means the server sent the request's final frame.
+	InterruptOutcomeEndFrame InterruptOutcome = "end_frame"
+	// InterruptOutcomeError means the server reported an error for the request.
+	InterruptOutcomeError InterruptOutcome = "error"
+	// InterruptOutcomeQuiet means no frame arrived for the request during the quiet period.
+	InterruptOutcomeQuiet InterruptOutcome = "quiet"
+	// InterruptOutcomeClosed means the connection closed before the interrupt was confirmed.
+	InterruptOutcomeClosed InterruptOutcome = "closed"
+)
+
+// Interruption tracks an interrupt sent for one request. It completes when the server sends
+// the request's end frame or an error for it, or when no frame has arrived for the session's
+// interrupt quiet period. Frames for the request that arrive after the interrupt was sent are
+// not delivered to callbacks, the Events channel or the request handle. After a quiet
+// completion they keep being filtered until another quiet period passes without one.
+type Interruption struct {
+	reqID string
+
+	mu      sync.Mutex
+	timer   *time.Timer
+	outcome InterruptOutcome
+	err     error
+	done    chan struct{}
+}
+
+func newInterruption(reqID string) *Interruption {
+	return &Interruption{reqID: reqID, done: make(chan struct{})}
+}
+
+// ReqID returns the interrupted request ID.
+func (i *Interruption) ReqID() string {
+	return i.reqID
+}
+
+// Done returns a channel that is closed when the interruption completes.
+func (i *Interruption) Done() <-chan struct{} {
+	return i.done
+}
+
+// Wait blocks until the interruption completes or ctx is done. It returns the server error
+// for InterruptOutcomeError, an error matching ErrSessionClosed for InterruptOutcomeClosed,
+// nil otherwise, or the context error if ctx ended first.
+func (i *Interruption) Wait(ctx context.Context) error {
+	select {
+	case <-i.done:
+		return i.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Outcome returns how the interruption completed, or an empty string while it is pending.
+func (i *Interruption) Outcome() InterruptOutcome {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.outcome
+}
+
+// Err returns the error that completed the interruption, if any.
+func (i *Interruption) Err() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.err
+}
+
+// touch restarts the quiet period, or the retention of a completed interruption, after a
+// late frame.
+func (i *Interruption) touch(quiet time.Duration) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.timer != nil {
+		i.timer.Reset(quiet)
+	}
+}
+
+// retain runs forget once the completed interruption has seen no frame for d.
+func (i *Interruption) retain(d time.Duration, forget func()) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.timer = time.AfterFunc(d, forget)
+}
+
+// finish completes the interruption. It reports false if it had already completed.
+func (i *Interruption) finish(outcome InterruptOutcome, err error) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.outcome != "" {
+		return false
+	}
+	i.outcome = outcome
+	i.err = err
+	if i.timer != nil {
+		i.timer.Stop()
+	}
+	close(i.done)
+	return true
+}
+
+// InterruptRequest stops the request with the given ID, which need not be the most recent one.
+// The returned Interruption completes once the server has stopped emitting frames for it.
+// Interrupting a request whose interruption is still pending resends the interrupt and
+// returns the pending Interruption.
+func (s *AvatarSession) InterruptRequest(reqID string) (*Interruption, error) {
+	return s.InterruptRequestContext(context.Background(), reqID)
+}
+
+// InterruptRequestContext is like InterruptRequest but stops waiting for the write when ctx is done.
+func (s *AvatarSession) InterruptRequestContext(ctx context.Context, reqID string) (*Interruption, error) {
+	if s == nil {
+		return nil, errors.New("interrupt request: session is nil")
+	}
+	if reqID == "" {
+		return nil, errors.New("interrupt request: empty request ID")
+	}
+	return s.interrupt(ctx, "interrupt request", reqID)
+}
+
+// interrupt sends ClientInterrupt for reqID and starts filtering its late frames.
+func (s *AvatarSession) interrupt(ctx context.Context, op string, reqID string) (*Interruption, error) {
+	s.mu.Lock()
+	writer := s.writer
+	if writer == nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("%s: websocket connection is not established", op)
+	}
+	// Register before sending so frames racing with the interrupt are filtered too.
+	interruption := s.interrupts[reqID]
+	pending := interruption != nil && interruption.Outcome() == ""
+	if !pending {
+		interruption = newInterruption(reqID)
+		if s.interrupts == nil {
+			s.interrupts = make(map[string]*Interruption)
+		}
+		s.interrupts[reqID] = interruption
+	}
+	s.mu.Unlock()
+
+	msg := &message.Message{
+		Type: message.MessageType_MESSAGE_CLIENT_INTERRUPT,
+		Data: &message.Message_ClientInterrupt{
+			ClientInterrupt: &message.ClientInterrupt{
+				ReqId: reqID,
+			},
+		},
+	}
+
+	data, err := proto.Marshal(msg)
+	if err == nil {
+		err = s.sendMessage(ctx, writer, data, false)
+		if err != nil {
+			err = fmt.Errorf("%s: write message: %w", op, err)
+		}
+	} else {
+		err = fmt.Errorf("%s: marshal message: %w", op, err)
+	}
+	if err != nil {
+		if !pending {
+			s.mu.Lock()
+			if s.interrupts[reqID] == interruption {
+				delete(s.interrupts, reqID)
+			}
+			s.mu.Unlock()
+			interruption.finish(InterruptOutcomeClosed, err)
+		}
+		return nil, err
+	}
+
+	if !pending {
+		quiet := s.interruptQuietPeriod()
+		interruption.mu.Lock()
+		if interruption.outcome == "" {
+			interruption.timer = time.AfterFunc(quiet, func() {
+				s.finishInterruption(interruption, InterruptOutcomeQuiet, nil)
+			})
+		}
+		interruption.mu.Unlock()
+	}
+
+	// Clear current request so next SendAudio creates a new one
+	s.mu.Lock()
+	if s.current != nil && s.current.id == reqID {
+		s.current = nil
+	}
+	s.mu.Unlock()
+	s.currentConnLogger().Debug("interrupt sent", "req_id", reqID)
+
+	return interruption, nil
+}
+
+// interruptQuietPeriod returns the configured quiet period or its default.
+func (s *AvatarSession) interruptQuietPeriod() time.Duration {
+	if s.config.InterruptQuietPeriod > 0 {
+		return s.config.InterruptQuietPeriod
+	}
+	return defaultInterruptQuietPeriod
+}
+
+// filterInterruptedFrame reports whether frame belongs to an interrupted request and must not
+// be delivered. The request's end frame completes its interruption.
+func (s *AvatarSession) filterInterruptedFrame(frame AnimationFrame) bool {
+	s.mu.Lock()
+	interruption := s.interrupts[frame.ReqID]
+	if interruption != nil && frame.End {
+		delete(s.interrupts, frame.ReqID)
+	}
+	s.mu.Unlock()
+
+	if interruption == nil {
+		return false
+	}
+	if frame.End {
+		s.finishInterruption(interruption, InterruptOutcomeEndFrame, nil)
+	} else {
+		interruption.touch(s.interruptQuietPeriod())
+	}
+	return true
+}
+
+// interruptFailed completes the interruption of reqID, if any, with a server error.
+func (s *AvatarSession) interruptFailed(reqID string, err error) {
+	s.mu.Lock()
+	interruption := s.interrupts[reqID]
+	delete(s.interrupts, reqID)
+	s.mu.Unlock()
+
+	if interruption != nil {
+		s.finishInterruption(interruption, InterruptOutcomeError, err)
+	}
+}
+
+// finishInterruption completes interruption and ends the interrupted request's handle.
+// The request is released together with the interruption so that, once Wait returns, the ID
+// can be reused without the old interruption ending the new request.
+//
+// An interruption that completed after its quiet period keeps filtering late frames until
+// another quiet period passes without one, and is then forgotten so the set of tracked
+// interruptions does not grow with the lifetime of the session.
+func (s *AvatarSession) finishInterruption(interruption *Interruption, outcome InterruptOutcome, err error) {
+	s.mu.Lock()
+	if !interruption.finish(outcome, err) {
+		s.mu.Unlock()
+		return
+	}
+	if outcome == InterruptOutcomeQuiet && s.interrupts[interruption.reqID] == interruption {
+		interruption.retain(s.interruptQuietPeriod(), func() { s.forgetInterruption(interruption) })
+	}
+	req := s.requests[interruption.reqID]
+	delete(s.requests, interruption.reqID)
+	s.mu.Unlock()
+
+	s.currentConnLogger().Debug("interrupt completed", "req_id", interruption.reqID, "outcome", outcome)
+	if req != nil {
+		req.finish(ErrRequestInterrupted)
+	}
+}
+
+// forgetInterruption stops tracking a completed interruption unless its ID was reused.
+func (s *AvatarSession) forgetInterruption(interruption *Interruption) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.interrupts[interruption.reqID] == interruption {
+		delete(s.interrupts, interruption.reqID)
+	}
+}
+
+// takeInterruptsLocked removes and returns every tracked interruption.
+// Callers must hold s.mu.
+func (s *AvatarSession) takeInterruptsLocked() []*Interruption {
+	pending := make([]*Interruption, 0, len(s.interrupts))
+	for id, interruption := range s.interrupts {
+		pending = append(pending, interruption)
+		delete(s.interrupts, id)
+	}
+	return pending
+}
@@ -0,0 +1,196 @@
+package avatarsdkgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	message "github.com/spatialwalk/avatar-sdk-go/proto/generated"
+)
+
+func TestInterruptRequestFiltersLateFrames(t *testing.T) {
+	ingress := newFakeIngress(t)
+	frames := make(chan AnimationFrame, 8)
+	session := startTestSession(t, ingress,
+		WithOnAnimationFrame(func(frame AnimationFrame) { frames <- frame }),
+		WithInterruptQuietPeriod(time.Minute),
+	)
+	defer session.Close() // nolint:errcheck
+	serverConn := ingress.accept(t)
+
+	older, err := session.BeginRequest()
+	if err != nil {
+		t.Fatalf("BeginRequest returned error: %v", err)
+	}
+	newer, err := session.BeginRequest()
+	if err != nil {
+		t.Fatalf("BeginRequest returned error: %v", err)
+	}
+
+	interruption, err := session.InterruptRequest(older.ID())
+	if err != nil {
+		t.Fatalf("InterruptRequest returned error: %v", err)
+	}
+	if got := serverConn.next(t).GetClientInterrupt().GetReqId(); got != older.ID() {
+		t.Fatalf("expected interrupt for %q, got %q", older.ID(), got)
+	}
+
+	serverConn.send(t, animationMessage(serverConn.connectionID, older.ID(), false))
+	serverConn.send(t, animationMessage(serverConn.connectionID, newer.ID(), false))
+	serverConn.send(t, animationMessage(serverConn.connectionID, older.ID(), true))
+
+	select {
+	case frame := <-frames:
+		if frame.ReqID != newer.ID() {
+			t.Fatalf("expected only frames for %q, got %q", newer.ID(), frame.ReqID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for animation frame")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := interruption.Wait(ctx); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	if outcome := interruption.Outcome(); outcome != InterruptOutcomeEndFrame {
+		t.Fatalf("expected outcome %q, got %q", InterruptOutcomeEndFrame, outcome)
+	}
+	if err := older.Wait(ctx); !errors.Is(err, ErrRequestInterrupted) {
+		t.Fatalf("expected ErrRequestInterrupted, got %v", err)
+	}
+	select {
+	case frame := <-frames:
+		t.Fatalf("unexpected frame for %q", frame.ReqID)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestInterruptRequestQuietPeriod(t *testing.T) {
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress, WithInterruptQuietPeriod(30*time.Millisecond))
+	defer session.Close() // nolint:errcheck
+	serverConn := ingress.accept(t)
+
+	start := time.Now()
+	interruption, err := session.InterruptRequest("req-1")
+	if err != nil {
+		t.Fatalf("InterruptRequest returned error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := interruption.Wait(ctx); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	if outcome := interruption.Outcome(); outcome != InterruptOutcomeQuiet {
+		t.Fatalf("expected outcome %q, got %q", InterruptOutcomeQuiet, outcome)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Fatalf("expected the quiet period to elapse, completed after %v", elapsed)
+	}
+
+	// Reusing the ID for a new request stops filtering its frames.
+	req, err := session.BeginRequest(WithRequestID("req-1"))
+	if err != nil {
+		t.Fatalf("BeginRequest returned error: %v", err)
+	}
+	frames := req.Frames()
+	serverConn.send(t, animationMessage(serverConn.connectionID, "req-1", true))
+	assertRequestFrames(t, req, frames, []bool{true})
+}
+
+func TestInterruptRequestForgetsQuietInterruptions(t *testing.T) {
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress, WithInterruptQuietPeriod(10*time.Millisecond))
+	defer session.Close() // nolint:errcheck
+	ingress.accept(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for i := 0; i < 50; i++ {
+		interruption, err := session.InterruptRequest(fmt.Sprintf("req-%d", i))
+		if err != nil {
+			t.Fatalf("InterruptRequest returned error: %v", err)
+		}
+		if err := interruption.Wait(ctx); err != nil {
+			t.Fatalf("Wait returned error: %v", err)
+		}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		session.mu.Lock()
+		tracked := len(session.interrupts)
+		session.mu.Unlock()
+		if tracked == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected completed interruptions to be forgotten, %d still tracked", tracked)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestInterruptRequestServerError(t *testing.T) {
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress, WithInterruptQuietPeriod(time.Minute))
+	defer session.Close() // nolint:errcheck
+	serverConn := ingress.accept(t)
+
+	interruption, err := session.InterruptRequest("req-1")
+	if err != nil {
+		t.Fatalf("InterruptRequest returned error: %v", err)
+	}
+	serverConn.send(t, &message.Message{
+		Type: message.MessageType_MESSAGE_SERVER_ERROR,
+		Data: &message.Message_ServerError{
+			ServerError: &message.ServerError{ReqId: "req-1", Code: 500, Message: "boom"},
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	var sdkErr *AvatarSDKError
+	if err := interruption.Wait(ctx); !errors.As(err, &sdkErr) || sdkErr.ReqID != "req-1" {
+		t.Fatalf("expected server error for req-1, got %v", err)
+	}
+	if outcome := interruption.Outcome(); outcome != InterruptOutcomeError {
+		t.Fatalf("expected outcome %q, got %q", InterruptOutcomeError, outcome)
+	}
+}
+
+func TestInterruptRequestSessionClosed(t *testing.T) {
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress, WithInterruptQuietPeriod(time.Minute))
+	ingress.accept(t)
+
+	interruption, err := session.InterruptRequest("req-1")
+	if err != nil {
+		t.Fatalf("InterruptRequest returned error: %v", err)
+	}
+	again, err := session.InterruptRequest("req-1")
+	if err != nil {
+		t.Fatalf("second InterruptRequest returned error: %v", err)
+	}
+	if again != interruption {
+		t.Fatal("expected a pending interruption to be reused")
+	}
+	if err := session.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := interruption.Wait(ctx); !errors.Is(err, ErrSessionClosed) {
+		t.Fatalf("expected ErrSessionClosed, got %v", err)
+	}
+	if outcome := interruption.Outcome(); outcome != InterruptOutcomeClosed {
+		t.Fatalf("expected outcome %q, got %q", InterruptOutcomeClosed, outcome)
+	}
+	if _, err := session.InterruptRequest("req-1"); err == nil {
+		t.Fatal("expected InterruptRequest on a closed session to fail")
+	}
+}
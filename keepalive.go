@@ -0,0 +1,69 @@
+package avatarsdkgo
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// keepaliveEnabled reports whether the session sends keepalive pings.
+func (s *AvatarSession) keepaliveEnabled() bool {
+	return s.config.KeepaliveInterval > 0
+}
+
+// keepaliveWindow is how long the connection may stay silent before it is considered dead.
+func (s *AvatarSession) keepaliveWindow() time.Duration {
+	return s.config.KeepaliveInterval + s.config.KeepaliveTimeout
+}
+
+// startKeepalive arms the read deadline on conn and pings the server every KeepaliveInterval
+// until writer is closed. Each pong pushes the read deadline out again, so a peer that stops
+// answering makes the read loop fail with a timeout.
+func (s *AvatarSession) startKeepalive(conn *websocket.Conn, writer *connWriter) {
+	if !s.keepaliveEnabled() {
+		return
+	}
+
+	window := s.keepaliveWindow()
+	_ = conn.SetReadDeadline(time.Now().Add(window))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(window))
+	})
+
+	go func() {
+		ticker := time.NewTicker(s.config.KeepaliveInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-writer.done:
+				return
+			case <-ticker.C:
+				// WriteControl may be called concurrently with the writer goroutine.
+				deadline := time.Now().Add(s.config.KeepaliveTimeout)
+				if err := conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// keepaliveTimeout converts a read deadline expiry into a typed keepalive timeout.
+// It returns nil for any other read error.
+func (s *AvatarSession) keepaliveTimeout(err error, connectionID string) *AvatarSDKError {
+	var netErr net.Error
+	if !s.keepaliveEnabled() || !errors.As(err, &netErr) || !netErr.Timeout() {
+		return nil
+	}
+
+	return &AvatarSDKError{
+		Code:         ErrorCodeKeepaliveTimeout,
+		Message:      fmt.Sprintf("no pong received within %s: %v", s.keepaliveWindow(), err),
+		Phase:        "keepalive",
+		ConnectionID: connectionID,
+	}
+}
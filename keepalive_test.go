@@ -0,0 +1,140 @@
+package avatarsdkgo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	message "github.com/spatialwalk/avatar-sdk-go/proto/generated"
+	"google.golang.org/protobuf/proto"
+)
+
+// newSilentIngress completes the handshake and then stops reading, so pings are never answered.
+func newSilentIngress(t *testing.T) string {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool { return true },
+	}
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close() // nolint:errcheck
+
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+		data, err := proto.Marshal(&message.Message{
+			Type: message.MessageType_MESSAGE_SERVER_CONFIRM_SESSION,
+			Data: &message.Message_ServerConfirmSession{
+				ServerConfirmSession: &message.ServerConfirmSession{ConnectionId: "conn-silent"},
+			},
+		})
+		if err != nil {
+			t.Errorf("marshal confirm: %v", err)
+			return
+		}
+		if err := conn.WriteMessage(websocket.BinaryMessage, data); err != nil {
+			return
+		}
+
+		<-release
+	}))
+	t.Cleanup(func() {
+		close(release)
+		server.Close()
+	})
+
+	return strings.Replace(server.URL, "http", "ws", 1)
+}
+
+func TestKeepaliveDetectsDeadPeer(t *testing.T) {
+	errs := make(chan error, 4)
+	closed := make(chan struct{})
+
+	session := NewAvatarSession(
+		WithAvatarID("avatar-123"),
+		WithAppID("app-123"),
+		WithIngressEndpointURL(newSilentIngress(t)),
+		WithKeepalive(20*time.Millisecond, 30*time.Millisecond),
+		WithOnError(func(err error) { errs <- err }),
+		WithOnClose(func() { close(closed) }),
+	)
+	session.sessionToken = "session-token-123"
+
+	if _, err := session.Start(context.Background()); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+
+	select {
+	case err := <-errs:
+		var sdkErr *AvatarSDKError
+		if !errors.As(err, &sdkErr) {
+			t.Fatalf("expected AvatarSDKError, got %T: %v", err, err)
+		}
+		if sdkErr.Code != ErrorCodeKeepaliveTimeout {
+			t.Fatalf("expected code %q, got %q", ErrorCodeKeepaliveTimeout, sdkErr.Code)
+		}
+		if sdkErr.ConnectionID != "conn-silent" {
+			t.Fatalf("expected connection ID conn-silent, got %q", sdkErr.ConnectionID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for keepalive timeout")
+	}
+
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for OnClose")
+	}
+}
+
+func TestKeepaliveKeepsResponsivePeerAlive(t *testing.T) {
+	errs := make(chan error, 4)
+
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress,
+		WithKeepalive(10*time.Millisecond, 20*time.Millisecond),
+		WithOnError(func(err error) { errs <- err }),
+	)
+	defer session.Close() // nolint:errcheck
+	serverConn := ingress.accept(t)
+
+	// Well past several keepalive windows; pongs from the server must keep the read deadline moving.
+	time.Sleep(200 * time.Millisecond)
+
+	select {
+	case err := <-errs:
+		t.Fatalf("unexpected error: %v", err)
+	default:
+	}
+
+	reqID, err := session.SendAudio([]byte{0x01}, true)
+	if err != nil {
+		t.Fatalf("SendAudio returned error: %v", err)
+	}
+	if got := serverConn.next(t).GetClientAudioInput().GetReqId(); got != reqID {
+		t.Fatalf("expected audio for %q, got %q", reqID, got)
+	}
+}
+
+func TestWithKeepaliveDefaultsTimeoutToInterval(t *testing.T) {
+	cfg := defaultSessionConfig()
+	WithKeepalive(time.Second, 0)(cfg)
+
+	if cfg.KeepaliveInterval != time.Second {
+		t.Fatalf("expected KeepaliveInterval 1s, got %v", cfg.KeepaliveInterval)
+	}
+	if cfg.KeepaliveTimeout != time.Second {
+		t.Fatalf("expected KeepaliveTimeout to default to the interval, got %v", cfg.KeepaliveTimeout)
+	}
+}
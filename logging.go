@@ -0,0 +1,55 @@
+package avatarsdkgo
+
+import (
+	"context"
+	"log/slog"
+)
+
+// logger returns the session logger annotated with the avatar ID. Without WithLogger the
+// records are discarded, so sessions stay silent unless the caller opts in.
+// Records must never carry the API key, session token or egress credentials.
+func (s *AvatarSession) logger() *slog.Logger {
+	if s.config == nil || s.config.Logger == nil {
+		return discardLogger
+	}
+	return s.config.Logger.With("avatar_id", s.config.AvatarID)
+}
+
+// fallbackLogger is like logger but uses slog.Default() without WithLogger. It is reserved for
+// the problems the SDK has always reported on its own: recovered callback panics and an
+// encoder bitrate that could not be applied.
+func (s *AvatarSession) fallbackLogger() *slog.Logger {
+	if s.config == nil {
+		return slog.Default()
+	}
+	if s.config.Logger != nil {
+		return s.logger()
+	}
+	return slog.Default().With("avatar_id", s.config.AvatarID)
+}
+
+// connLogger returns the session logger annotated with connectionID.
+func (s *AvatarSession) connLogger(connectionID string) *slog.Logger {
+	return s.logger().With("connection_id", connectionID)
+}
+
+// currentConnLogger returns the session logger annotated with the active connection ID, if any.
+func (s *AvatarSession) currentConnLogger() *slog.Logger {
+	s.mu.Lock()
+	connectionID := s.connectionID
+	s.mu.Unlock()
+	if connectionID == "" {
+		return s.logger()
+	}
+	return s.connLogger(connectionID)
+}
+
+// discardLogger drops every record.
+var discardLogger = slog.New(discardHandler{})
+
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
@@ -0,0 +1,169 @@
+package avatarsdkgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	message "github.com/spatialwalk/avatar-sdk-go/proto/generated"
+)
+
+// logBuffer is a concurrency-safe sink for a JSON slog handler.
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) records(t *testing.T) []map[string]any {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("failed to decode log record %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func (b *logBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestWithLoggerEmitsStructuredRecords(t *testing.T) {
+	console, _ := newTokenConsole(t, func(int32) int { return http.StatusOK })
+	ingress := newFakeIngress(t)
+	sink := &logBuffer{}
+	logger := slog.New(slog.NewJSONHandler(sink, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	session := NewAvatarSession(
+		WithAPIKey("secret-api-key"),
+		WithExpireAt(time.Now().Add(time.Hour)),
+		WithConsoleEndpointURL(console.URL),
+		WithAvatarID("avatar-123"),
+		WithAppID("app-123"),
+		WithIngressEndpointURL(ingress.url()),
+		WithLogger(logger),
+	)
+	if err := session.Init(context.Background()); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	connectionID, err := session.Start(context.Background())
+	if err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	serverConn := ingress.accept(t)
+
+	req, err := session.BeginRequest()
+	if err != nil {
+		t.Fatalf("BeginRequest returned error: %v", err)
+	}
+	if err := req.End(); err != nil {
+		t.Fatalf("End returned error: %v", err)
+	}
+	serverConn.send(t, animationMessage(connectionID, req.ID(), true))
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for range req.Frames() {
+	}
+	if err := req.Wait(ctx); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	if err := session.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	byMessage := make(map[string]map[string]any)
+	for _, record := range sink.records(t) {
+		if record["avatar_id"] != "avatar-123" {
+			t.Fatalf("expected avatar_id on every record, got %v", record)
+		}
+		byMessage[record["msg"].(string)] = record
+	}
+	for _, want := range []struct {
+		msg   string
+		reqID bool
+	}{
+		{"avatar session connected", false},
+		{"request started", true},
+		{"request audio ended", true},
+		{"request completed", true},
+		{"avatar session closed", false},
+	} {
+		record, ok := byMessage[want.msg]
+		if !ok {
+			t.Fatalf("expected a %q record, got %s", want.msg, sink.String())
+		}
+		if record["connection_id"] != connectionID {
+			t.Fatalf("expected connection_id %q on %q, got %v", connectionID, want.msg, record["connection_id"])
+		}
+		if want.reqID && record["req_id"] != req.ID() {
+			t.Fatalf("expected req_id %q on %q, got %v", req.ID(), want.msg, record["req_id"])
+		}
+	}
+
+	output := sink.String()
+	for _, secret := range []string{"secret-api-key", "session-token-"} {
+		if strings.Contains(output, secret) {
+			t.Fatalf("log output contains secret %q: %s", secret, output)
+		}
+	}
+}
+
+func TestSessionWithoutLoggerOnlyReportsPanics(t *testing.T) {
+	sink := &logBuffer{}
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(sink, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer slog.SetDefault(previous)
+
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress,
+		WithOnAnimationFrame(func(AnimationFrame) { panic("animation frame failed") }),
+	)
+	serverConn := ingress.accept(t)
+	serverConn.send(t, &message.Message{
+		Type: message.MessageType_MESSAGE_SERVER_ERROR,
+		Data: &message.Message_ServerError{
+			ServerError: &message.ServerError{ConnectionId: serverConn.connectionID, Code: 500, Message: "boom"},
+		},
+	})
+	serverConn.send(t, animationMessage(serverConn.connectionID, "req-1", true))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(sink.String(), "callback panicked") {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the panic to reach slog.Default")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := session.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	for _, record := range sink.records(t) {
+		if record["msg"] != "callback panicked" {
+			t.Fatalf("expected only the panic on slog.Default, got %v", record)
+		}
+	}
+}
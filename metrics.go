@@ -0,0 +1,298 @@
+package avatarsdkgo
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// MetricsRecorder receives request milestones from a session, timestamped with the session clock.
+// Methods are called synchronously from the goroutine that reached the milestone, including the
+// connection's read loop, so they must be fast and must not block.
+type MetricsRecorder interface {
+	// RequestStarted is called when a request is created by SendAudio, BeginRequest,
+	// NewAudioWriter or StreamAudio.
+	RequestStarted(reqID string, at time.Time)
+	// AudioSent is called after an audio chunk of bytes input bytes was handed to the connection.
+	AudioSent(reqID string, bytes int, end bool, at time.Time)
+	// FrameReceived is called for every animation frame delivered for a request.
+	FrameReceived(frame AnimationFrame)
+	// RequestFinished is called once when a request completes. err is nil after the final
+	// frame and otherwise reports the server error, interruption or closed connection.
+	RequestFinished(reqID string, err error, at time.Time)
+}
+
+var (
+	// DefaultLatencyBuckets are in seconds.
+	DefaultLatencyBuckets = []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	// DefaultCountBuckets suit frames per request.
+	DefaultCountBuckets = []float64{1, 10, 50, 100, 250, 500, 1000, 2500, 5000}
+	// DefaultByteBuckets suit audio bytes per request.
+	DefaultByteBuckets = []float64{1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20, 16 << 20}
+)
+
+// Histogram is a bucketed distribution of observed values.
+type Histogram struct {
+	// Bounds are the ascending upper bounds of the buckets.
+	Bounds []float64
+	// Counts has one entry per bound, counting observations at or below it and above the
+	// previous bound, plus a final entry for observations above every bound.
+	Counts []uint64
+	Count  uint64
+	Sum    float64
+	Min    float64
+	Max    float64
+}
+
+func newHistogram(bounds []float64) Histogram {
+	return Histogram{
+		Bounds: append([]float64(nil), bounds...),
+		Counts: make([]uint64, len(bounds)+1),
+	}
+}
+
+func (h *Histogram) observe(v float64) {
+	h.Counts[sort.SearchFloat64s(h.Bounds, v)]++
+	if h.Count == 0 || v < h.Min {
+		h.Min = v
+	}
+	if h.Count == 0 || v > h.Max {
+		h.Max = v
+	}
+	h.Count++
+	h.Sum += v
+}
+
+// Mean returns the average observation, or zero if there are none.
+func (h Histogram) Mean() float64 {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / float64(h.Count)
+}
+
+func (h Histogram) clone() Histogram {
+	h.Bounds = append([]float64(nil), h.Bounds...)
+	h.Counts = append([]uint64(nil), h.Counts...)
+	return h
+}
+
+// RequestMetrics holds the milestones of a single request. Zero times mean the milestone
+// has not been reached.
+type RequestMetrics struct {
+	ReqID        string
+	StartedAt    time.Time
+	FirstAudioAt time.Time
+	EndSentAt    time.Time
+	FirstFrameAt time.Time
+	FinalFrameAt time.Time
+	FinishedAt   time.Time
+	Frames       int
+	AudioBytes   int
+	Err          error
+}
+
+// TimeToFirstFrame is the time from the first audio chunk to the first animation frame.
+func (m RequestMetrics) TimeToFirstFrame() time.Duration {
+	if m.FirstAudioAt.IsZero() || m.FirstFrameAt.IsZero() {
+		return 0
+	}
+	return m.FirstFrameAt.Sub(m.FirstAudioAt)
+}
+
+// EndToFinalFrame is the time from sending end=true to the final animation frame.
+func (m RequestMetrics) EndToFinalFrame() time.Duration {
+	if m.EndSentAt.IsZero() || m.FinalFrameAt.IsZero() {
+		return 0
+	}
+	return m.FinalFrameAt.Sub(m.EndSentAt)
+}
+
+// MetricsSnapshot aggregates finished requests of a session.
+type MetricsSnapshot struct {
+	RequestsStarted  uint64
+	RequestsFinished uint64
+	RequestsFailed   uint64
+	// TimeToFirstFrame and EndToFinalFrame are in seconds.
+	TimeToFirstFrame Histogram
+	EndToFinalFrame  Histogram
+	FramesPerRequest Histogram
+	AudioBytes       Histogram
+}
+
+// maxRetainedRequests bounds how many finished requests InMemoryMetrics keeps for Request.
+const maxRetainedRequests = 1024
+
+// InMemoryMetrics is a MetricsRecorder that keeps per-request milestones and per-session
+// histograms in memory. Histograms are updated when a request finishes. The most recent
+// finished requests remain available through Request. It is safe for concurrent use.
+type InMemoryMetrics struct {
+	mu       sync.Mutex
+	active   map[string]*RequestMetrics
+	finished map[string]*RequestMetrics
+	order    []string // finished request IDs, oldest first
+	snapshot MetricsSnapshot
+}
+
+// NewInMemoryMetrics returns an empty InMemoryMetrics using the default histogram buckets.
+func NewInMemoryMetrics() *InMemoryMetrics {
+	return &InMemoryMetrics{
+		active:   make(map[string]*RequestMetrics),
+		finished: make(map[string]*RequestMetrics),
+		snapshot: MetricsSnapshot{
+			TimeToFirstFrame: newHistogram(DefaultLatencyBuckets),
+			EndToFinalFrame:  newHistogram(DefaultLatencyBuckets),
+			FramesPerRequest: newHistogram(DefaultCountBuckets),
+			AudioBytes:       newHistogram(DefaultByteBuckets),
+		},
+	}
+}
+
+// RequestStarted implements MetricsRecorder.
+func (m *InMemoryMetrics) RequestStarted(reqID string, at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.active[reqID] = &RequestMetrics{ReqID: reqID, StartedAt: at}
+	m.snapshot.RequestsStarted++
+}
+
+// AudioSent implements MetricsRecorder.
+func (m *InMemoryMetrics) AudioSent(reqID string, bytes int, end bool, at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	req := m.active[reqID]
+	if req == nil {
+		return
+	}
+	if req.FirstAudioAt.IsZero() {
+		req.FirstAudioAt = at
+	}
+	req.AudioBytes += bytes
+	if end {
+		req.EndSentAt = at
+	}
+}
+
+// FrameReceived implements MetricsRecorder.
+func (m *InMemoryMetrics) FrameReceived(frame AnimationFrame) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	req := m.active[frame.ReqID]
+	if req == nil {
+		return
+	}
+	if req.FirstFrameAt.IsZero() {
+		req.FirstFrameAt = frame.ReceivedAt
+	}
+	req.Frames++
+	if frame.End {
+		req.FinalFrameAt = frame.ReceivedAt
+	}
+}
+
+// RequestFinished implements MetricsRecorder.
+func (m *InMemoryMetrics) RequestFinished(reqID string, err error, at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	req := m.active[reqID]
+	if req == nil {
+		return
+	}
+	delete(m.active, reqID)
+	req.FinishedAt = at
+	req.Err = err
+
+	m.snapshot.RequestsFinished++
+	if err != nil {
+		m.snapshot.RequestsFailed++
+	}
+	if !req.FirstFrameAt.IsZero() && !req.FirstAudioAt.IsZero() {
+		m.snapshot.TimeToFirstFrame.observe(req.TimeToFirstFrame().Seconds())
+	}
+	if !req.FinalFrameAt.IsZero() && !req.EndSentAt.IsZero() {
+		m.snapshot.EndToFinalFrame.observe(req.EndToFinalFrame().Seconds())
+	}
+	m.snapshot.FramesPerRequest.observe(float64(req.Frames))
+	m.snapshot.AudioBytes.observe(float64(req.AudioBytes))
+
+	if _, exists := m.finished[reqID]; !exists {
+		m.order = append(m.order, reqID)
+	}
+	m.finished[reqID] = req
+	if len(m.order) > maxRetainedRequests {
+		delete(m.finished, m.order[0])
+		m.order = m.order[1:]
+	}
+}
+
+// Request returns the milestones of an active or recently finished request.
+func (m *InMemoryMetrics) Request(reqID string) (RequestMetrics, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if req := m.active[reqID]; req != nil {
+		return *req, true
+	}
+	if req := m.finished[reqID]; req != nil {
+		return *req, true
+	}
+	return RequestMetrics{}, false
+}
+
+// Snapshot returns a copy of the per-session aggregates.
+func (m *InMemoryMetrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot := m.snapshot
+	snapshot.TimeToFirstFrame = snapshot.TimeToFirstFrame.clone()
+	snapshot.EndToFinalFrame = snapshot.EndToFinalFrame.clone()
+	snapshot.FramesPerRequest = snapshot.FramesPerRequest.clone()
+	snapshot.AudioBytes = snapshot.AudioBytes.clone()
+	return snapshot
+}
+
+// requestStarted logs and records a new request. Callers must not hold s.mu.
+func (s *AvatarSession) requestStarted(req *Request) {
+	s.currentConnLogger().Debug("request started", "req_id", req.id)
+	s.stats.update(func(stats *SessionStats) {
+		stats.RequestsStarted++
+	})
+	if recorder := s.config.Metrics; recorder != nil {
+		s.invokeCallback("MetricsRecorder.RequestStarted", func() { recorder.RequestStarted(req.id, s.timeNow()) })
+	}
+}
+
+// recordAudioSent records an audio chunk of inBytes input bytes that was written to the
+// connection as outBytes of payload.
+func (s *AvatarSession) recordAudioSent(req *Request, inBytes int, outBytes int, end bool) {
+	s.stats.update(func(stats *SessionStats) {
+		stats.AudioBytesIn += uint64(inBytes)
+		stats.EncodedBytesOut += uint64(outBytes)
+	})
+	if recorder := s.config.Metrics; recorder != nil {
+		s.invokeCallback("MetricsRecorder.AudioSent", func() { recorder.AudioSent(req.id, inBytes, end, s.timeNow()) })
+	}
+}
+
+// recordFrame records a delivered animation frame.
+func (s *AvatarSession) recordFrame(frame AnimationFrame) {
+	s.stats.update(func(stats *SessionStats) {
+		stats.FramesReceived++
+		stats.LastFrameAt = frame.ReceivedAt
+	})
+	if recorder := s.config.Metrics; recorder != nil {
+		s.invokeCallback("MetricsRecorder.FrameReceived", func() { recorder.FrameReceived(frame) })
+	}
+}
+
+// recordRequestFinished records a completed request.
+func (s *AvatarSession) recordRequestFinished(reqID string, err error) {
+	if s == nil || s.config == nil {
+		return
+	}
+	s.stats.update(func(stats *SessionStats) {
+		stats.countRequestFinished(err)
+	})
+	if recorder := s.config.Metrics; recorder != nil {
+		s.invokeCallback("MetricsRecorder.RequestFinished", func() { recorder.RequestFinished(reqID, err, s.timeNow()) })
+	}
+}
@@ -0,0 +1,190 @@
+package avatarsdkgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInMemoryMetricsRecordsMilestones(t *testing.T) {
+	base := time.Date(2025, time.October, 27, 14, 30, 34, 0, time.UTC)
+	at := func(ms int) time.Time { return base.Add(time.Duration(ms) * time.Millisecond) }
+	metrics := NewInMemoryMetrics()
+
+	metrics.RequestStarted("req-1", at(0))
+	metrics.AudioSent("req-1", 640, false, at(5))
+	metrics.AudioSent("req-1", 640, false, at(25))
+	metrics.AudioSent("req-1", 0, true, at(40))
+	metrics.FrameReceived(AnimationFrame{ReqID: "req-1", ReceivedAt: at(85)})
+	metrics.FrameReceived(AnimationFrame{ReqID: "req-1", ReceivedAt: at(120)})
+	metrics.FrameReceived(AnimationFrame{ReqID: "req-1", End: true, ReceivedAt: at(340)})
+	metrics.RequestFinished("req-1", nil, at(340))
+
+	req, ok := metrics.Request("req-1")
+	if !ok {
+		t.Fatal("expected req-1 to be retained")
+	}
+	if got := req.TimeToFirstFrame(); got != 80*time.Millisecond {
+		t.Fatalf("expected time to first frame 80ms, got %v", got)
+	}
+	if got := req.EndToFinalFrame(); got != 300*time.Millisecond {
+		t.Fatalf("expected end to final frame 300ms, got %v", got)
+	}
+	if req.Frames != 3 || req.AudioBytes != 1280 {
+		t.Fatalf("expected 3 frames and 1280 bytes, got %d frames and %d bytes", req.Frames, req.AudioBytes)
+	}
+
+	// A second request fails before producing any frame.
+	failure := errors.New("server error")
+	metrics.RequestStarted("req-2", at(400))
+	metrics.AudioSent("req-2", 320, false, at(400))
+	metrics.RequestFinished("req-2", failure, at(450))
+
+	// Late milestones for unknown or finished requests are ignored.
+	metrics.FrameReceived(AnimationFrame{ReqID: "req-1", ReceivedAt: at(500)})
+	metrics.AudioSent("unknown", 100, false, at(500))
+
+	snapshot := metrics.Snapshot()
+	if snapshot.RequestsStarted != 2 || snapshot.RequestsFinished != 2 || snapshot.RequestsFailed != 1 {
+		t.Fatalf("unexpected request counts %+v", snapshot)
+	}
+	if snapshot.TimeToFirstFrame.Count != 1 || snapshot.EndToFinalFrame.Count != 1 {
+		t.Fatalf("expected one latency observation each, got %d and %d", snapshot.TimeToFirstFrame.Count, snapshot.EndToFinalFrame.Count)
+	}
+	// 0.08s falls in the (0.05, 0.1] bucket and 0.3s in (0.25, 0.5].
+	if snapshot.TimeToFirstFrame.Counts[3] != 1 {
+		t.Fatalf("unexpected time to first frame buckets %v", snapshot.TimeToFirstFrame.Counts)
+	}
+	if snapshot.EndToFinalFrame.Counts[5] != 1 {
+		t.Fatalf("unexpected end to final frame buckets %v", snapshot.EndToFinalFrame.Counts)
+	}
+	if snapshot.FramesPerRequest.Count != 2 || snapshot.FramesPerRequest.Min != 0 || snapshot.FramesPerRequest.Max != 3 {
+		t.Fatalf("unexpected frames per request %+v", snapshot.FramesPerRequest)
+	}
+	if mean := snapshot.AudioBytes.Mean(); mean != 800 {
+		t.Fatalf("expected mean audio bytes 800, got %v", mean)
+	}
+
+	failed, ok := metrics.Request("req-2")
+	if !ok || !errors.Is(failed.Err, failure) || failed.TimeToFirstFrame() != 0 {
+		t.Fatalf("unexpected failed request metrics %+v", failed)
+	}
+
+	// Snapshots are copies.
+	snapshot.AudioBytes.Counts[0] = 99
+	if metrics.Snapshot().AudioBytes.Counts[0] == 99 {
+		t.Fatal("expected Snapshot to return a copy of the histograms")
+	}
+}
+
+func TestInMemoryMetricsBoundsRetainedRequests(t *testing.T) {
+	metrics := NewInMemoryMetrics()
+	now := time.Now()
+	for i := 0; i <= maxRetainedRequests; i++ {
+		reqID := fmt.Sprintf("req-%d", i)
+		if i == 0 {
+			reqID = "oldest"
+		}
+		metrics.RequestStarted(reqID, now)
+		metrics.RequestFinished(reqID, nil, now)
+	}
+	if _, ok := metrics.Request("oldest"); ok {
+		t.Fatal("expected the oldest finished request to be evicted")
+	}
+	if got := metrics.Snapshot().RequestsFinished; got != maxRetainedRequests+1 {
+		t.Fatalf("expected %d finished requests, got %d", maxRetainedRequests+1, got)
+	}
+}
+
+func TestAvatarSessionReportsMetrics(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, time.October, 27, 14, 30, 34, 0, time.UTC)}
+	metrics := NewInMemoryMetrics()
+
+	ingress := newFakeIngress(t)
+	session := NewAvatarSession(
+		WithAvatarID("avatar-123"),
+		WithAppID("app-123"),
+		WithIngressEndpointURL(ingress.url()),
+		WithMetricsRecorder(metrics),
+	)
+	session.sessionToken = "session-token-123"
+	session.now = clock.Now
+	if _, err := session.Start(context.Background()); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	defer session.Close() // nolint:errcheck
+	serverConn := ingress.accept(t)
+
+	req, err := session.BeginRequest()
+	if err != nil {
+		t.Fatalf("BeginRequest returned error: %v", err)
+	}
+	frames := req.Frames()
+
+	clock.advance(10 * time.Millisecond)
+	if err := req.Write(make([]byte, 640)); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	clock.advance(20 * time.Millisecond)
+	if err := req.End(); err != nil {
+		t.Fatalf("End returned error: %v", err)
+	}
+	serverConn.next(t)
+	serverConn.next(t)
+
+	clock.advance(50 * time.Millisecond)
+	serverConn.send(t, animationMessage(serverConn.connectionID, req.ID(), false))
+	select {
+	case <-frames:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the first frame")
+	}
+	clock.advance(150 * time.Millisecond)
+	serverConn.send(t, animationMessage(serverConn.connectionID, req.ID(), true))
+	assertRequestFrames(t, req, frames, []bool{true})
+	if err := req.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+
+	got, ok := metrics.Request(req.ID())
+	if !ok {
+		t.Fatalf("expected metrics for %q", req.ID())
+	}
+	if got.FinishedAt.IsZero() || got.Err != nil {
+		t.Fatalf("expected a successful finished request, got %+v", got)
+	}
+	if got.TimeToFirstFrame() != 70*time.Millisecond {
+		t.Fatalf("expected time to first frame 70ms, got %v", got.TimeToFirstFrame())
+	}
+	if got.EndToFinalFrame() != 200*time.Millisecond {
+		t.Fatalf("expected end to final frame 200ms, got %v", got.EndToFinalFrame())
+	}
+	if got.Frames != 2 || got.AudioBytes != 640 {
+		t.Fatalf("expected 2 frames and 640 bytes, got %d frames and %d bytes", got.Frames, got.AudioBytes)
+	}
+
+	snapshot := metrics.Snapshot()
+	if snapshot.RequestsStarted != 1 || snapshot.RequestsFinished != 1 || snapshot.RequestsFailed != 0 {
+		t.Fatalf("unexpected request counts %+v", snapshot)
+	}
+}
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
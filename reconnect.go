@@ -0,0 +1,220 @@
+package avatarsdkgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	defaultReconnectInitialBackoff = 500 * time.Millisecond
+	defaultReconnectMaxBackoff     = 30 * time.Second
+	defaultReconnectMultiplier     = 2.0
+)
+
+// ReconnectPolicy configures automatic reconnection after the websocket connection drops.
+// Zero fields select the defaults documented on each field.
+type ReconnectPolicy struct {
+	// MaxAttempts limits consecutive reconnect attempts. Zero retries until the session is closed.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first attempt. Defaults to 500ms.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts. Defaults to 30s.
+	MaxBackoff time.Duration
+	// Multiplier grows the delay after each failed attempt. Defaults to 2.
+	Multiplier float64
+	// Jitter randomizes each delay by up to this fraction in either direction, e.g. 0.2 for ±20%.
+	// Zero disables jitter; values above 1 are treated as 1.
+	Jitter float64
+}
+
+// ReconnectEvent describes a connection that was re-established after a drop.
+type ReconnectEvent struct {
+	// Attempt is the 1-based attempt that succeeded.
+	Attempt int
+	// ConnectionID is the connection ID confirmed by the new handshake.
+	ConnectionID string
+	// PreviousConnectionID is the connection ID of the dropped connection.
+	PreviousConnectionID string
+	// Cause is the error that dropped the previous connection.
+	Cause error
+}
+
+// reconnectAttempt tracks a reconnect loop so Close can cancel it.
+type reconnectAttempt struct {
+	cancel context.CancelFunc
+}
+
+func (p ReconnectPolicy) withDefaults() ReconnectPolicy {
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = defaultReconnectInitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = defaultReconnectMaxBackoff
+	}
+	if p.MaxBackoff < p.InitialBackoff {
+		p.MaxBackoff = p.InitialBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = defaultReconnectMultiplier
+	}
+	if p.Jitter < 0 {
+		p.Jitter = 0
+	}
+	if p.Jitter > 1 {
+		p.Jitter = 1
+	}
+	return p
+}
+
+// backoff returns the delay before the given 1-based attempt.
+func (p ReconnectPolicy) backoff(attempt int) time.Duration {
+	delay := float64(p.InitialBackoff)
+	for i := 1; i < attempt && delay < float64(p.MaxBackoff); i++ {
+		delay *= p.Multiplier
+	}
+	if delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		delay *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(delay)
+}
+
+// reconnect replaces the dropped connection, retrying according to the session's
+// ReconnectPolicy. It runs on the dropped connection's read loop goroutine.
+func (s *AvatarSession) reconnect(ctx context.Context, dropped *websocket.Conn, cause error) {
+	s.mu.Lock()
+	if s.conn != dropped {
+		s.mu.Unlock()
+		return
+	}
+	if s.state == SessionStateDraining {
+		// Shutdown is waiting for this connection; its requests have already failed.
+		s.mu.Unlock()
+		_ = s.closeConn(dropped)
+		return
+	}
+	writer := s.writer
+	previousConnectionID := s.connectionID
+	attemptCtx, cancel := context.WithCancel(context.Background())
+	attempt := &reconnectAttempt{cancel: cancel}
+	s.reconnecting = attempt
+	s.setStateLocked(SessionStateReconnecting)
+	s.conn = nil
+	s.writer = nil
+	s.current = nil
+	s.mu.Unlock()
+	defer cancel()
+
+	writer.close()
+	_ = dropped.Close()
+
+	policy := s.config.Reconnect.withDefaults()
+	lastErr := cause
+	for n := 1; policy.MaxAttempts <= 0 || n <= policy.MaxAttempts; n++ {
+		if !sleepContext(attemptCtx, policy.backoff(n)) {
+			return
+		}
+
+		conn, connectionID, err := s.reconnectOnce(attemptCtx)
+		if err != nil {
+			if attemptCtx.Err() != nil {
+				return
+			}
+			s.connLogger(previousConnectionID).Warn("reconnect attempt failed", "attempt", n, "error", err)
+			lastErr = err
+			if errors.Is(err, ErrSessionTokenNotRenewable) {
+				// Every further attempt would present the rejected token again.
+				break
+			}
+			continue
+		}
+
+		s.mu.Lock()
+		if s.reconnecting != attempt {
+			// Close won the race; discard the new connection.
+			s.mu.Unlock()
+			_ = conn.Close()
+			return
+		}
+		s.reconnecting = nil
+		s.attachConnLocked(conn, connectionID)
+		s.setStateLocked(SessionStateReady)
+		s.mu.Unlock()
+
+		go s.readLoop(ctx, conn)
+		s.recordReconnect()
+		s.connLogger(connectionID).Info("avatar session reconnected", "attempt", n, "previous_connection_id", previousConnectionID)
+
+		event := ReconnectEvent{
+			Attempt:              n,
+			ConnectionID:         connectionID,
+			PreviousConnectionID: previousConnectionID,
+			Cause:                cause,
+		}
+		s.enqueueFrom(conn, func() {
+			if s.config.OnReconnect != nil {
+				s.invokeCallback("OnReconnect", func() { s.config.OnReconnect(event) })
+			}
+			s.publish(event)
+		})
+		return
+	}
+
+	s.mu.Lock()
+	if s.reconnecting != attempt {
+		s.mu.Unlock()
+		return
+	}
+	detached := s.detachLocked()
+	s.mu.Unlock()
+
+	if errors.Is(lastErr, ErrSessionTokenNotRenewable) {
+		_ = s.teardown(detached, fmt.Errorf("avatar session reconnect: %w", lastErr))
+		return
+	}
+	_ = s.teardown(detached, fmt.Errorf("avatar session reconnect: giving up after %d attempts: %w", policy.MaxAttempts, lastErr))
+}
+
+// reconnectOnce dials a replacement connection, minting a new session token first
+// when the server reports the current one as expired.
+func (s *AvatarSession) reconnectOnce(ctx context.Context) (*websocket.Conn, string, error) {
+	s.mu.Lock()
+	sessionToken := s.sessionToken
+	s.mu.Unlock()
+
+	conn, connectionID, err := s.connect(ctx, sessionToken)
+	var sdkErr *AvatarSDKError
+	if err == nil || !errors.As(err, &sdkErr) || sdkErr.Code != ErrorCodeSessionTokenExpired {
+		return conn, connectionID, err
+	}
+
+	if err := s.renewSessionToken(ctx); err != nil {
+		return nil, "", err
+	}
+
+	s.mu.Lock()
+	sessionToken = s.sessionToken
+	s.mu.Unlock()
+
+	return s.connect(ctx, sessionToken)
+}
+
+// sleepContext waits for d and reports whether ctx is still active.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,526 @@
+package avatarsdkgo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestReconnectPolicyBackoff(t *testing.T) {
+	policy := ReconnectPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     3,
+	}.withDefaults()
+
+	expected := []time.Duration{
+		100 * time.Millisecond,
+		300 * time.Millisecond,
+		900 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+	for i, want := range expected {
+		if got := policy.backoff(i + 1); got != want {
+			t.Fatalf("attempt %d: expected backoff %v, got %v", i+1, want, got)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		got := policy.backoff(1)
+		if got < 50*time.Millisecond || got > 150*time.Millisecond {
+			t.Fatalf("expected jittered backoff within ±50%% of 100ms, got %v", got)
+		}
+	}
+}
+
+func TestReconnectPolicyDefaults(t *testing.T) {
+	policy := ReconnectPolicy{Jitter: 2}.withDefaults()
+
+	if policy.InitialBackoff != defaultReconnectInitialBackoff {
+		t.Fatalf("expected default initial backoff, got %v", policy.InitialBackoff)
+	}
+	if policy.MaxBackoff != defaultReconnectMaxBackoff {
+		t.Fatalf("expected default max backoff, got %v", policy.MaxBackoff)
+	}
+	if policy.Multiplier != defaultReconnectMultiplier {
+		t.Fatalf("expected default multiplier, got %v", policy.Multiplier)
+	}
+	if policy.Jitter != 1 {
+		t.Fatalf("expected jitter to be capped at 1, got %v", policy.Jitter)
+	}
+}
+
+func TestReconnectAfterServerDrop(t *testing.T) {
+	reconnects := make(chan ReconnectEvent, 1)
+
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress,
+		WithReconnect(ReconnectPolicy{InitialBackoff: 10 * time.Millisecond}),
+		WithOnReconnect(func(event ReconnectEvent) { reconnects <- event }),
+	)
+	defer session.Close() // nolint:errcheck
+
+	first := ingress.accept(t)
+	req, err := session.BeginRequest()
+	if err != nil {
+		t.Fatalf("BeginRequest returned error: %v", err)
+	}
+
+	_ = first.conn.Close()
+
+	select {
+	case event := <-reconnects:
+		if event.Attempt != 1 {
+			t.Fatalf("expected reconnect on attempt 1, got %d", event.Attempt)
+		}
+		if event.PreviousConnectionID != "conn-1" {
+			t.Fatalf("expected previous connection ID conn-1, got %q", event.PreviousConnectionID)
+		}
+		if event.ConnectionID != "conn-2" {
+			t.Fatalf("expected new connection ID conn-2, got %q", event.ConnectionID)
+		}
+		if event.Cause == nil {
+			t.Fatal("expected reconnect event to carry the drop cause")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for reconnect")
+	}
+
+	if err := req.Wait(context.Background()); !errors.Is(err, ErrSessionClosed) {
+		t.Fatalf("expected in-flight request to fail with ErrSessionClosed, got %v", err)
+	}
+
+	second := ingress.accept(t)
+	reqID, err := session.SendAudio([]byte{0x01, 0x02}, true)
+	if err != nil {
+		t.Fatalf("SendAudio after reconnect returned error: %v", err)
+	}
+	if got := second.next(t).GetClientAudioInput().GetReqId(); got != reqID {
+		t.Fatalf("expected audio for %q on the new connection, got %q", reqID, got)
+	}
+}
+
+func TestReconnectAfterServerCloseFrame(t *testing.T) {
+	reconnects := make(chan ReconnectEvent, 1)
+
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress,
+		WithReconnect(ReconnectPolicy{InitialBackoff: 10 * time.Millisecond}),
+		WithOnReconnect(func(event ReconnectEvent) { reconnects <- event }),
+	)
+	defer session.Close() // nolint:errcheck
+
+	first := ingress.accept(t)
+	req, err := session.BeginRequest()
+	if err != nil {
+		t.Fatalf("BeginRequest returned error: %v", err)
+	}
+
+	// An ingress restart closes connections gracefully.
+	sendServerClose(t, first, websocket.CloseGoingAway)
+
+	select {
+	case event := <-reconnects:
+		if event.ConnectionID != "conn-2" {
+			t.Fatalf("expected new connection ID conn-2, got %q", event.ConnectionID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for reconnect")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := req.Wait(ctx); !errors.Is(err, ErrSessionClosed) {
+		t.Fatalf("expected in-flight request to fail with ErrSessionClosed, got %v", err)
+	}
+	if state := session.State(); state != SessionStateReady {
+		t.Fatalf("expected ready state after reconnect, got %q", state)
+	}
+}
+
+func TestReconnectReissuesExpiredSessionToken(t *testing.T) {
+	var issued atomic.Int32
+	console := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		token := fmt.Sprintf("session-token-%d", issued.Add(1))
+		_ = json.NewEncoder(w).Encode(sessionTokenResponse{SessionToken: token})
+	}))
+	defer console.Close()
+
+	var tokenExpired atomic.Bool
+	var usedTokens []string
+	usedTokensCh := make(chan string, 8)
+	ingress := newFakeIngressWithAuth(t, func(r *http.Request) int {
+		token := r.Header.Get("X-Session-Key")
+		usedTokensCh <- token
+		if tokenExpired.Load() && token == "session-token-1" {
+			return http.StatusUnauthorized
+		}
+		return http.StatusOK
+	})
+
+	reconnects := make(chan ReconnectEvent, 1)
+	session := NewAvatarSession(
+		WithAPIKey("api-key"),
+		WithExpireAt(time.Now().Add(time.Hour)),
+		WithConsoleEndpointURL(console.URL),
+		WithAvatarID("avatar-123"),
+		WithAppID("app-123"),
+		WithIngressEndpointURL(ingress.url()),
+		WithReconnect(ReconnectPolicy{InitialBackoff: 10 * time.Millisecond}),
+		WithOnReconnect(func(event ReconnectEvent) { reconnects <- event }),
+	)
+	defer session.Close() // nolint:errcheck
+
+	if err := session.Init(context.Background()); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	if _, err := session.Start(context.Background()); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	first := ingress.accept(t)
+
+	tokenExpired.Store(true)
+	_ = first.conn.Close()
+
+	select {
+	case event := <-reconnects:
+		if event.ConnectionID != "conn-2" {
+			t.Fatalf("expected new connection ID conn-2, got %q", event.ConnectionID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for reconnect")
+	}
+
+	close(usedTokensCh)
+	for token := range usedTokensCh {
+		usedTokens = append(usedTokens, token)
+	}
+	expected := []string{"session-token-1", "session-token-1", "session-token-2"}
+	if strings.Join(usedTokens, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected dial tokens %v, got %v", expected, usedTokens)
+	}
+	if got := issued.Load(); got != 2 {
+		t.Fatalf("expected Init to run twice, got %d", got)
+	}
+}
+
+func TestReconnectRenewsTokenAfterExpireAtPassed(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, time.October, 27, 14, 30, 34, 0, time.UTC)}
+	expireAt := clock.Now().Add(time.Hour)
+
+	// The console remembers the expiry of every token; the ingress rejects expired ones.
+	var mu sync.Mutex
+	expiries := make(map[string]int64)
+	console := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload sessionTokenRequest
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("failed to decode request payload: %v", err)
+		}
+		mu.Lock()
+		token := fmt.Sprintf("session-token-%d", len(expiries)+1)
+		expiries[token] = payload.ExpireAt
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(sessionTokenResponse{SessionToken: token})
+	}))
+	defer console.Close()
+	ingress := newFakeIngressWithAuth(t, func(r *http.Request) int {
+		mu.Lock()
+		defer mu.Unlock()
+		if expiries[r.Header.Get("X-Session-Key")] <= clock.Now().Unix() {
+			return http.StatusUnauthorized
+		}
+		return http.StatusOK
+	})
+
+	reconnects := make(chan ReconnectEvent, 1)
+	session := NewAvatarSession(
+		WithAPIKey("api-key"),
+		WithExpireAt(expireAt),
+		WithConsoleEndpointURL(console.URL),
+		WithAvatarID("avatar-123"),
+		WithAppID("app-123"),
+		WithIngressEndpointURL(ingress.url()),
+		WithReconnect(ReconnectPolicy{MaxAttempts: 3, InitialBackoff: 10 * time.Millisecond}),
+		WithOnReconnect(func(event ReconnectEvent) { reconnects <- event }),
+	)
+	session.now = clock.Now
+	defer session.Close() // nolint:errcheck
+
+	if err := session.Init(context.Background()); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	if _, err := session.Start(context.Background()); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	first := ingress.accept(t)
+
+	// The configured ExpireAt is already in the past when the connection drops.
+	clock.advance(2 * time.Hour)
+	_ = first.conn.Close()
+
+	select {
+	case <-reconnects:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for reconnect")
+	}
+	want := clock.Now().Add(time.Hour)
+	if got := session.TokenExpiresAt(); !got.Equal(want) {
+		t.Fatalf("expected renewed token to expire at %v, got %v", want, got)
+	}
+	mu.Lock()
+	renewed := expiries["session-token-2"]
+	mu.Unlock()
+	if renewed != want.Unix() {
+		t.Fatalf("expected the renewal to request expiry %d, got %d", want.Unix(), renewed)
+	}
+}
+
+func TestReconnectInvalidatesTokenSource(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		newSource func(fetched *atomic.Int32) TokenSource
+	}{
+		{"caching", func(fetched *atomic.Int32) TokenSource {
+			return NewCachingTokenSource(tokenSourceFunc(func(context.Context) (string, time.Time, error) {
+				return fmt.Sprintf("source-token-%d", fetched.Add(1)), time.Now().Add(time.Hour), nil
+			}), 0)
+		}},
+		{"custom", func(fetched *atomic.Int32) TokenSource {
+			return &rotatingTokenSource{fetched: fetched}
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var fetched atomic.Int32
+			var tokenExpired atomic.Bool
+			ingress := newFakeIngressWithAuth(t, func(r *http.Request) int {
+				if tokenExpired.Load() && r.Header.Get("X-Session-Key") == "source-token-1" {
+					return http.StatusUnauthorized
+				}
+				return http.StatusOK
+			})
+
+			reconnects := make(chan ReconnectEvent, 1)
+			session := NewAvatarSession(
+				WithTokenSource(tc.newSource(&fetched)),
+				WithAvatarID("avatar-123"),
+				WithAppID("app-123"),
+				WithIngressEndpointURL(ingress.url()),
+				WithReconnect(ReconnectPolicy{MaxAttempts: 3, InitialBackoff: 10 * time.Millisecond}),
+				WithOnReconnect(func(event ReconnectEvent) { reconnects <- event }),
+			)
+			defer session.Close() // nolint:errcheck
+
+			if err := session.Init(context.Background()); err != nil {
+				t.Fatalf("Init returned error: %v", err)
+			}
+			if _, err := session.Start(context.Background()); err != nil {
+				t.Fatalf("Start returned error: %v", err)
+			}
+			first := ingress.accept(t)
+
+			// The token is far from its expiry, so only invalidation makes the source replace it.
+			tokenExpired.Store(true)
+			_ = first.conn.Close()
+
+			select {
+			case <-reconnects:
+			case <-time.After(2 * time.Second):
+				t.Fatal("timed out waiting for reconnect")
+			}
+			session.mu.Lock()
+			token := session.sessionToken
+			session.mu.Unlock()
+			if token != "source-token-2" {
+				t.Fatalf("expected the session to reconnect with source-token-2, got %q", token)
+			}
+			if got := fetched.Load(); got != 2 {
+				t.Fatalf("expected the source to fetch twice, got %d", got)
+			}
+		})
+	}
+}
+
+// rotatingTokenSource keeps returning its current token until it is invalidated.
+type rotatingTokenSource struct {
+	fetched *atomic.Int32
+
+	mu    sync.Mutex
+	token string
+}
+
+func (r *rotatingTokenSource) Token(context.Context) (string, time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.token == "" {
+		r.token = fmt.Sprintf("source-token-%d", r.fetched.Add(1))
+	}
+	return r.token, time.Now().Add(time.Hour), nil
+}
+
+func (r *rotatingTokenSource) Invalidate() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.token = ""
+}
+
+func TestReconnectStopsWhenStaticTokenRejected(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	refresh := WithTokenRefresh(TokenRefreshPolicy{})
+	for _, tc := range []struct {
+		name   string
+		source TokenSource
+		opts   []SessionOption
+	}{
+		{"static", StaticTokenSource("static-token", time.Time{}), nil},
+		{"cached static", NewCachingTokenSource(StaticTokenSource("static-token", time.Time{}), 0), nil},
+		{"static with refresh", StaticTokenSource("static-token", expiresAt), []SessionOption{refresh}},
+		{"cached static with refresh", NewCachingTokenSource(StaticTokenSource("static-token", expiresAt), 0), []SessionOption{refresh}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var rejecting atomic.Bool
+			var dials atomic.Int32
+			ingress := newFakeIngressWithAuth(t, func(*http.Request) int {
+				dials.Add(1)
+				if rejecting.Load() {
+					return http.StatusUnauthorized
+				}
+				return http.StatusOK
+			})
+
+			errs := make(chan error, 8)
+			closed := make(chan struct{})
+			session := NewAvatarSession(append([]SessionOption{
+				WithTokenSource(tc.source),
+				WithAvatarID("avatar-123"),
+				WithAppID("app-123"),
+				WithIngressEndpointURL(ingress.url()),
+				WithReconnect(ReconnectPolicy{InitialBackoff: 5 * time.Millisecond}),
+				WithOnError(func(err error) { errs <- err }),
+				WithOnClose(func() { close(closed) }),
+			}, tc.opts...)...)
+			if err := session.Init(context.Background()); err != nil {
+				t.Fatalf("Init returned error: %v", err)
+			}
+			if _, err := session.Start(context.Background()); err != nil {
+				t.Fatalf("Start returned error: %v", err)
+			}
+			first := ingress.accept(t)
+
+			rejecting.Store(true)
+			_ = first.conn.Close()
+
+			select {
+			case <-closed:
+			case <-time.After(2 * time.Second):
+				t.Fatal("timed out waiting for the session to close")
+			}
+			var final error
+			for len(errs) > 0 {
+				final = <-errs
+			}
+			if !errors.Is(final, ErrSessionTokenNotRenewable) {
+				t.Fatalf("expected ErrSessionTokenNotRenewable, got %v", final)
+			}
+			// One dial for Start and one rejected redial; no further attempts.
+			if got := dials.Load(); got != 2 {
+				t.Fatalf("expected 2 dials, got %d", got)
+			}
+		})
+	}
+}
+
+func TestReconnectGivesUpAfterMaxAttempts(t *testing.T) {
+	var rejecting atomic.Bool
+	ingress := newFakeIngressWithAuth(t, func(*http.Request) int {
+		if rejecting.Load() {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusOK
+	})
+
+	errs := make(chan error, 8)
+	closed := make(chan struct{})
+	var reconnected atomic.Bool
+	session := startTestSession(t, ingress,
+		WithReconnect(ReconnectPolicy{MaxAttempts: 2, InitialBackoff: 5 * time.Millisecond}),
+		WithOnReconnect(func(ReconnectEvent) { reconnected.Store(true) }),
+		WithOnError(func(err error) { errs <- err }),
+		WithOnClose(func() { close(closed) }),
+	)
+
+	first := ingress.accept(t)
+	rejecting.Store(true)
+	_ = first.conn.Close()
+
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for OnClose")
+	}
+	if reconnected.Load() {
+		t.Fatal("expected no successful reconnect")
+	}
+
+	var final error
+	for len(errs) > 0 {
+		final = <-errs
+	}
+	if final == nil || !strings.Contains(final.Error(), "giving up after 2 attempts") {
+		t.Fatalf("expected final reconnect error, got %v", final)
+	}
+
+	if _, err := session.SendAudio([]byte{0x01}, true); err == nil {
+		t.Fatal("expected SendAudio to fail after reconnect gave up")
+	}
+}
+
+func TestCloseCancelsReconnect(t *testing.T) {
+	closed := make(chan struct{})
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress,
+		WithReconnect(ReconnectPolicy{InitialBackoff: time.Hour}),
+		WithOnClose(func() { close(closed) }),
+	)
+
+	first := ingress.accept(t)
+	_ = first.conn.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for session.State() != SessionStateReconnecting {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for reconnect to begin")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := session.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for OnClose")
+	}
+
+	if state := session.State(); state != SessionStateClosed {
+		t.Fatalf("expected closed state after Close, got %q", state)
+	}
+	select {
+	case conn := <-ingress.conns:
+		t.Fatalf("expected cancelled reconnect not to dial, got %q", conn.connectionID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,185 @@
+package avatarsdkgo
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrSessionClosed is reported for requests that were still in flight when the connection closed.
+	ErrSessionClosed = errors.New("avatar session closed")
+	// ErrTooManyRequests is reported when a new request would exceed WithMaxConcurrentRequests.
+	ErrTooManyRequests = errors.New("too many concurrent requests")
+	// ErrDuplicateRequestID is reported when WithRequestID names a request that is still in flight.
+	ErrDuplicateRequestID = errors.New("duplicate request ID")
+	// ErrAudioStreamBroken is reported for requests that lost a chunk encoded by the internal
+	// Ogg Opus encoder, after which the rest of their Ogg stream cannot be decoded.
+	ErrAudioStreamBroken = errors.New("encoded audio stream broken")
+)
+
+// RequestOption configures a request started with BeginRequest or NewAudioWriter.
+type RequestOption func(*requestOptions)
+
+type requestOptions struct {
+	id string
+}
+
+// WithRequestID sends the request with a caller-supplied ID instead of a generated one.
+// The ID must not belong to another request that is still in flight.
+func WithRequestID(reqID string) RequestOption {
+	return func(o *requestOptions) {
+		o.id = reqID
+	}
+}
+
+// Request is a handle for a single audio request started with BeginRequest.
+// Frames and server errors carrying the request's ID are routed to the handle.
+// A Request is safe for concurrent use.
+type Request struct {
+	id      string
+	session *AvatarSession
+
+	// Guarded by the session send lock.
+	encoder    *OggOpusStreamEncoder
+	audioEnded bool
+	traced     bool
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	buffered bool // set by the first Frames call; frames are only kept from then on
+	pending  []AnimationFrame
+	finished bool
+	err      error
+	span     Span // started with the first audio chunk, ended by finish
+
+	framesOnce sync.Once
+	frames     chan AnimationFrame
+	done       chan struct{}
+}
+
+func newRequest(session *AvatarSession, id string) *Request {
+	r := &Request{
+		id:      id,
+		session: session,
+		frames:  make(chan AnimationFrame),
+		done:    make(chan struct{}),
+	}
+	r.cond = sync.NewCond(&r.mu)
+	return r
+}
+
+// ID returns the request ID sent to the server as req_id.
+func (r *Request) ID() string {
+	return r.id
+}
+
+// Write sends a chunk of audio for this request.
+// Audio must match the session's negotiated format unless the internal Ogg Opus encoder is enabled.
+func (r *Request) Write(audio []byte) error {
+	if err := r.session.sendRequestAudio(context.Background(), r, audio, false); err != nil {
+		return err
+	}
+	return nil
+}
+
+// End sends the terminating end=true message for this request. If it cannot be sent the
+// server never completes the request, so the request is finished with the error.
+func (r *Request) End() error {
+	if err := r.session.sendRequestAudio(context.Background(), r, nil, true); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Frames returns the animation frames received for this request, in wire order.
+// The channel is closed once the final frame has been delivered or the request failed.
+// Frames are buffered from the first call to Frames until read, so callers that use Frames
+// must drain the channel. Frames received before the first call are not replayed; call Frames
+// before sending audio to observe every frame. Callers that only Wait keep no frames.
+func (r *Request) Frames() <-chan AnimationFrame {
+	r.framesOnce.Do(func() {
+		r.mu.Lock()
+		r.buffered = true
+		r.mu.Unlock()
+		go r.pumpFrames()
+	})
+	return r.frames
+}
+
+// Done returns a channel that is closed when the request completes or fails.
+func (r *Request) Done() <-chan struct{} {
+	return r.done
+}
+
+// Wait blocks until the request completes, fails, or ctx is done.
+// It returns the request error, or the context error if ctx ended first.
+func (r *Request) Wait(ctx context.Context) error {
+	select {
+	case <-r.done:
+		return r.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Err returns the error that ended the request, or nil if it is still running or completed successfully.
+func (r *Request) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// deliver records a frame for Frames(). Frames arriving before Frames was first called or
+// after completion are ignored.
+func (r *Request) deliver(frame AnimationFrame) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.finished || !r.buffered {
+		return
+	}
+	r.pending = append(r.pending, frame)
+	r.cond.Signal()
+}
+
+// finish marks the request complete. Only the first call has an effect.
+func (r *Request) finish(err error) {
+	r.mu.Lock()
+	if r.finished {
+		r.mu.Unlock()
+		return
+	}
+	r.finished = true
+	r.err = err
+	span := r.span
+	r.span = nil
+	close(r.done)
+	r.cond.Broadcast()
+	r.mu.Unlock()
+
+	if span != nil {
+		endSpan(span, err)
+	}
+	r.session.recordRequestFinished(r.id, err)
+}
+
+func (r *Request) pumpFrames() {
+	defer close(r.frames)
+
+	for {
+		r.mu.Lock()
+		for len(r.pending) == 0 && !r.finished {
+			r.cond.Wait()
+		}
+		if len(r.pending) == 0 {
+			r.mu.Unlock()
+			return
+		}
+		frame := r.pending[0]
+		r.pending[0] = AnimationFrame{}
+		r.pending = r.pending[1:]
+		r.mu.Unlock()
+
+		r.frames <- frame
+	}
+}
@@ -0,0 +1,323 @@
+package avatarsdkgo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	message "github.com/spatialwalk/avatar-sdk-go/proto/generated"
+)
+
+func TestBeginRequestDemultiplexesFrames(t *testing.T) {
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress)
+	defer session.Close() // nolint:errcheck
+	serverConn := ingress.accept(t)
+
+	first, err := session.BeginRequest()
+	if err != nil {
+		t.Fatalf("BeginRequest returned error: %v", err)
+	}
+	second, err := session.BeginRequest()
+	if err != nil {
+		t.Fatalf("BeginRequest returned error: %v", err)
+	}
+	if first.ID() == second.ID() {
+		t.Fatalf("expected distinct request IDs, got %q twice", first.ID())
+	}
+
+	for _, req := range []*Request{first, second} {
+		if err := req.Write([]byte{0x01, 0x02}); err != nil {
+			t.Fatalf("Write returned error: %v", err)
+		}
+		if err := req.End(); err != nil {
+			t.Fatalf("End returned error: %v", err)
+		}
+	}
+	for _, want := range []struct {
+		reqID string
+		end   bool
+	}{{first.ID(), false}, {first.ID(), true}, {second.ID(), false}, {second.ID(), true}} {
+		input := serverConn.next(t).GetClientAudioInput()
+		if input.GetReqId() != want.reqID || input.GetEnd() != want.end {
+			t.Fatalf("expected audio input for %q end=%v, got %q end=%v", want.reqID, want.end, input.GetReqId(), input.GetEnd())
+		}
+	}
+
+	firstFrames := first.Frames()
+	secondFrames := second.Frames()
+
+	serverConn.send(t, animationMessage(serverConn.connectionID, first.ID(), false))
+	serverConn.send(t, animationMessage(serverConn.connectionID, second.ID(), false))
+	serverConn.send(t, animationMessage(serverConn.connectionID, "someone-else", false))
+	serverConn.send(t, animationMessage(serverConn.connectionID, first.ID(), false))
+	serverConn.send(t, animationMessage(serverConn.connectionID, second.ID(), true))
+	serverConn.send(t, animationMessage(serverConn.connectionID, first.ID(), true))
+
+	assertRequestFrames(t, first, firstFrames, []bool{false, false, true})
+	assertRequestFrames(t, second, secondFrames, []bool{false, true})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for _, req := range []*Request{first, second} {
+		if err := req.Wait(ctx); err != nil {
+			t.Fatalf("Wait returned error: %v", err)
+		}
+		if err := req.Err(); err != nil {
+			t.Fatalf("expected nil Err after completion, got %v", err)
+		}
+	}
+}
+
+func TestBeginRequestServerErrorFailsRequest(t *testing.T) {
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress)
+	defer session.Close() // nolint:errcheck
+	serverConn := ingress.accept(t)
+
+	req, err := session.BeginRequest()
+	if err != nil {
+		t.Fatalf("BeginRequest returned error: %v", err)
+	}
+
+	serverConn.send(t, &message.Message{
+		Type: message.MessageType_MESSAGE_SERVER_ERROR,
+		Data: &message.Message_ServerError{
+			ServerError: &message.ServerError{
+				ConnectionId: serverConn.connectionID,
+				ReqId:        req.ID(),
+				Code:         400,
+				Message:      "bad audio",
+			},
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	err = req.Wait(ctx)
+	var sdkErr *AvatarSDKError
+	if !errors.As(err, &sdkErr) {
+		t.Fatalf("expected AvatarSDKError, got %v", err)
+	}
+	if sdkErr.ReqID != req.ID() {
+		t.Fatalf("expected error for req %q, got %q", req.ID(), sdkErr.ReqID)
+	}
+	if req.Err() != err {
+		t.Fatalf("expected Err to return %v, got %v", err, req.Err())
+	}
+	if _, ok := <-req.Frames(); ok {
+		t.Fatal("expected Frames to be closed after failure")
+	}
+	if err := req.Write([]byte{0x01}); err == nil || !strings.Contains(err.Error(), "bad audio") {
+		t.Fatalf("expected Write on failed request to return the request error, got %v", err)
+	}
+}
+
+func TestBeginRequestFailsOnClose(t *testing.T) {
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress)
+	ingress.accept(t)
+
+	req, err := session.BeginRequest()
+	if err != nil {
+		t.Fatalf("BeginRequest returned error: %v", err)
+	}
+	if err := session.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := req.Wait(ctx); !errors.Is(err, ErrSessionClosed) {
+		t.Fatalf("expected ErrSessionClosed, got %v", err)
+	}
+}
+
+func TestRequestWriteAfterEnd(t *testing.T) {
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress)
+	defer session.Close() // nolint:errcheck
+	ingress.accept(t)
+
+	req, err := session.BeginRequest()
+	if err != nil {
+		t.Fatalf("BeginRequest returned error: %v", err)
+	}
+	if err := req.End(); err != nil {
+		t.Fatalf("End returned error: %v", err)
+	}
+	if err := req.Write([]byte{0x01}); err == nil || !strings.Contains(err.Error(), "already ended") {
+		t.Fatalf("expected already ended error, got %v", err)
+	}
+}
+
+func TestBeginRequestNoConnection(t *testing.T) {
+	session := NewAvatarSession()
+	if _, err := session.BeginRequest(); err == nil || !strings.Contains(err.Error(), "websocket connection is not established") {
+		t.Fatalf("expected websocket connection error, got %v", err)
+	}
+}
+
+func TestRequestWaitHonorsContext(t *testing.T) {
+	req := newRequest(nil, "req-1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := req.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestRequestWithoutFramesKeepsNoFrames(t *testing.T) {
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress)
+	defer session.Close() // nolint:errcheck
+	serverConn := ingress.accept(t)
+
+	req, err := session.BeginRequest()
+	if err != nil {
+		t.Fatalf("BeginRequest returned error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		serverConn.send(t, animationMessage(serverConn.connectionID, req.ID(), false))
+	}
+	serverConn.send(t, animationMessage(serverConn.connectionID, req.ID(), true))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := req.Wait(ctx); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	req.mu.Lock()
+	pending := len(req.pending)
+	req.mu.Unlock()
+	if pending != 0 {
+		t.Fatalf("expected no frames to be kept for a Wait-only caller, got %d", pending)
+	}
+	if _, ok := <-req.Frames(); ok {
+		t.Fatal("expected Frames to be closed without replaying earlier frames")
+	}
+}
+
+func animationMessage(connectionID string, reqID string, end bool) *message.Message {
+	return &message.Message{
+		Type: message.MessageType_MESSAGE_SERVER_RESPONSE_ANIMATION,
+		Data: &message.Message_ServerResponseAnimation{
+			ServerResponseAnimation: &message.ServerResponseAnimation{
+				ConnectionId: connectionID,
+				ReqId:        reqID,
+				End:          end,
+			},
+		},
+	}
+}
+
+func assertRequestFrames(t *testing.T, req *Request, frames <-chan AnimationFrame, ends []bool) {
+	t.Helper()
+	for i, end := range ends {
+		select {
+		case frame, ok := <-frames:
+			if !ok {
+				t.Fatalf("frames channel closed after %d frames, expected %d", i, len(ends))
+			}
+			if frame.ReqID != req.ID() {
+				t.Fatalf("expected frame for %q, got %q", req.ID(), frame.ReqID)
+			}
+			if frame.End != end {
+				t.Fatalf("expected end=%v for frame %d, got %v", end, i, frame.End)
+			}
+			if len(frame.Payload) == 0 {
+				t.Fatal("expected frame payload to carry the serialized message")
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for frame %d of %q", i, req.ID())
+		}
+	}
+	select {
+	case _, ok := <-frames:
+		if ok {
+			t.Fatalf("expected frames channel for %q to be closed", req.ID())
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for frames channel of %q to close", req.ID())
+	}
+}
+
+func TestBeginRequestWithRequestID(t *testing.T) {
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress)
+	defer session.Close() // nolint:errcheck
+	serverConn := ingress.accept(t)
+
+	req, err := session.BeginRequest(WithRequestID("utterance-1"))
+	if err != nil {
+		t.Fatalf("BeginRequest returned error: %v", err)
+	}
+	if req.ID() != "utterance-1" {
+		t.Fatalf("expected request ID %q, got %q", "utterance-1", req.ID())
+	}
+	if _, err := session.BeginRequest(WithRequestID("utterance-1")); !errors.Is(err, ErrDuplicateRequestID) {
+		t.Fatalf("expected ErrDuplicateRequestID, got %v", err)
+	}
+	if _, err := session.NewAudioWriter(WithRequestID("utterance-1")); !errors.Is(err, ErrDuplicateRequestID) {
+		t.Fatalf("expected ErrDuplicateRequestID from NewAudioWriter, got %v", err)
+	}
+
+	if err := req.End(); err != nil {
+		t.Fatalf("End returned error: %v", err)
+	}
+	if got := serverConn.next(t).GetClientAudioInput().GetReqId(); got != "utterance-1" {
+		t.Fatalf("expected audio for %q, got %q", "utterance-1", got)
+	}
+}
+
+func TestBeginRequestConcurrencyLimit(t *testing.T) {
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress, WithMaxConcurrentRequests(2))
+	defer session.Close() // nolint:errcheck
+	serverConn := ingress.accept(t)
+
+	first, err := session.BeginRequest()
+	if err != nil {
+		t.Fatalf("BeginRequest returned error: %v", err)
+	}
+	second, err := session.BeginRequest()
+	if err != nil {
+		t.Fatalf("BeginRequest returned error: %v", err)
+	}
+	if _, err := session.BeginRequest(); !errors.Is(err, ErrTooManyRequests) {
+		t.Fatalf("expected ErrTooManyRequests, got %v", err)
+	}
+	if _, err := session.SendAudio([]byte{0x01}, true); !errors.Is(err, ErrTooManyRequests) {
+		t.Fatalf("expected ErrTooManyRequests from SendAudio, got %v", err)
+	}
+
+	// Both requests stream concurrently; the second finishes first and frees a slot.
+	for _, req := range []*Request{first, second} {
+		if err := req.Write([]byte{0x01}); err != nil {
+			t.Fatalf("Write returned error: %v", err)
+		}
+	}
+	if err := second.End(); err != nil {
+		t.Fatalf("End returned error: %v", err)
+	}
+	serverConn.send(t, animationMessage(serverConn.connectionID, second.ID(), true))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for range second.Frames() {
+	}
+	if err := second.Wait(ctx); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+
+	third, err := session.BeginRequest()
+	if err != nil {
+		t.Fatalf("BeginRequest after a request completed returned error: %v", err)
+	}
+	if third.ID() == first.ID() {
+		t.Fatalf("expected a distinct request ID, got %q", third.ID())
+	}
+}
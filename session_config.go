@@ -1,6 +1,12 @@
 package avatarsdkgo
 
-import "time"
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
 
 // AudioFormat identifies the audio encoding negotiated for a session.
 type AudioFormat string
@@ -30,25 +36,81 @@ type OggOpusEncoderConfig struct {
 	Application     OggOpusApplication
 }
 
+// DispatchOverflowPolicy controls what happens when the callback queue is full.
+type DispatchOverflowPolicy string
+
+const (
+	// DispatchOverflowBlock makes the read loop wait until the callback queue has room.
+	DispatchOverflowBlock DispatchOverflowPolicy = "block"
+	// DispatchOverflowDropNewest discards the callback that did not fit into the queue.
+	DispatchOverflowDropNewest DispatchOverflowPolicy = "drop_newest"
+	// DispatchOverflowDropOldest discards the oldest queued callback to make room. State changes,
+	// reconnect notifications and the final error and close callbacks are never discarded.
+	DispatchOverflowDropOldest DispatchOverflowPolicy = "drop_oldest"
+)
+
+// OutboundQueuePolicy controls what SendAudio does when the outbound queue is full.
+type OutboundQueuePolicy string
+
+const (
+	// OutboundQueueBlock makes the sender wait until the queue has room.
+	OutboundQueueBlock OutboundQueuePolicy = "block"
+	// OutboundQueueDropOldestAudio discards the oldest queued audio chunk to make room.
+	// Chunks with end=true and interrupts are never dropped. Intended for PCM input, where
+	// losing a chunk does not corrupt the rest of the stream; Start rejects it when the
+	// internal Ogg Opus encoder is enabled.
+	OutboundQueueDropOldestAudio OutboundQueuePolicy = "drop_oldest_audio"
+	// OutboundQueueFailFast makes SendAudio return ErrQueueFull immediately. With the internal
+	// Ogg Opus encoder the rejected chunk has already been encoded, so its request also fails
+	// with ErrAudioStreamBroken.
+	OutboundQueueFailFast OutboundQueuePolicy = "fail_fast"
+)
+
 // SessionConfig captures the configuration used to build an AvatarSession.
 type SessionConfig struct {
-	AvatarID           string
-	APIKey             string
-	AppID              string
-	UseQueryAuth       bool // If true, send app/session credentials as URL query params (web-style auth). If false (default), send them as headers (mobile-style auth).
-	ExpireAt           time.Time
-	SampleRate         int
-	Bitrate            int
-	AudioFormat        AudioFormat
-	OggOpusEncoder     *OggOpusEncoderConfig
-	OnEncodedAudio     func(string, []byte)
-	TransportFrames    func([]byte, bool)
-	OnError            func(error)
-	OnClose            func()
-	ConsoleEndpointURL string
-	IngressEndpointURL string
-	LiveKitEgress      *LiveKitEgressConfig // If set, enables LiveKit egress mode - audio and animation are streamed to a LiveKit room via the egress service
-	AgoraEgress        *AgoraEgressConfig   // If set, enables Agora egress mode - audio and animation are streamed to an Agora channel via the egress service
+	AvatarID              string
+	APIKey                string
+	AppID                 string
+	UseQueryAuth          bool // If true, send app/session credentials as URL query params (web-style auth). If false (default), send them as headers (mobile-style auth).
+	ExpireAt              time.Time
+	ModelVersion          string      // Model version requested with the session token. Empty (default) uses the console default.
+	TokenSource           TokenSource // If set, Init obtains the session token from it instead of minting one with APIKey.
+	SampleRate            int
+	Bitrate               int
+	AudioFormat           AudioFormat
+	OggOpusEncoder        *OggOpusEncoderConfig
+	OnEncodedAudio        func(string, []byte)
+	TransportFrames       func([]byte, bool)
+	OnAnimationFrame      func(AnimationFrame)
+	OnError               func(error)
+	OnClose               func()
+	OnStateChange         func(old, new SessionState)
+	DispatchQueueSize     int                    // Maximum number of pending callbacks. Zero (default) leaves the queue unbounded.
+	DispatchOverflow      DispatchOverflowPolicy // Behavior when a bounded callback queue is full. Defaults to DispatchOverflowBlock.
+	EventBufferSize       int                    // Capacity of the channel returned by Events. Zero (default) uses 64.
+	Reconnect             *ReconnectPolicy       // If set, the session redials and repeats the handshake when the connection drops.
+	OnReconnect           func(ReconnectEvent)
+	OnCallbackPanic       func(CallbackPanic) // Receives panics recovered from the callbacks above. Nil (default) logs them through Logger, or slog.Default() without one.
+	TokenRefresh          *TokenRefreshPolicy // If set, Init starts a background refresher that mints new session tokens before they expire.
+	KeepaliveInterval     time.Duration       // Interval between websocket pings. Zero (default) disables keepalive.
+	KeepaliveTimeout      time.Duration       // How long to wait for a pong after the interval elapses before the connection is considered dead.
+	WriteTimeout          time.Duration       // Default write deadline for each outbound message. Zero (default) means no deadline.
+	OutboundQueueSize     int                 // Maximum number of queued outbound messages. Zero (default) disables the queue and SendAudio waits for each write.
+	OutboundQueuePolicy   OutboundQueuePolicy // Behavior when the outbound queue is full. Defaults to OutboundQueueBlock.
+	MaxConcurrentRequests int                 // Maximum number of requests in flight at once. Zero (default) means no limit.
+	InterruptQuietPeriod  time.Duration       // How long an interruption waits without frames for the interrupted request before it completes. Zero (default) uses one second.
+	ConsoleEndpointURL    string
+	IngressEndpointURL    string
+	HTTPClient            *http.Client         // Client for console API requests (Init and token refresh). Nil (default) uses http.DefaultClient.
+	WebSocketDialer       *websocket.Dialer    // Dialer for the ingress websocket (Start and reconnect). Nil (default) uses websocket.DefaultDialer.
+	MaxMessageSize        int64                // Maximum size in bytes of an inbound websocket message. Zero (default) means no limit.
+	ReadBufferSize        int                  // Websocket read buffer size in bytes. Zero (default) keeps the dialer's setting.
+	WriteBufferSize       int                  // Websocket write buffer size in bytes. Zero (default) keeps the dialer's setting.
+	Logger                *slog.Logger         // Receives structured records about the session. Nil (default) discards them, except recovered callback panics and encoder bitrate warnings, which go to slog.Default().
+	Metrics               MetricsRecorder      // Receives request latency and throughput milestones. Nil (default) disables metrics.
+	Tracer                Tracer               // Receives spans for token exchange, dial, handshake and requests. Nil (default) disables tracing.
+	LiveKitEgress         *LiveKitEgressConfig // If set, enables LiveKit egress mode - audio and animation are streamed to a LiveKit room via the egress service
+	AgoraEgress           *AgoraEgressConfig   // If set, enables Agora egress mode - audio and animation are streamed to an Agora channel via the egress service
 }
 
 // LiveKitEgressConfig contains configuration for streaming to a LiveKit room.
@@ -88,12 +150,14 @@ type SessionOption func(*SessionConfig)
 
 func defaultSessionConfig() *SessionConfig {
 	return &SessionConfig{
-		TransportFrames: func([]byte, bool) {},
-		OnError:         func(error) {},
-		OnClose:         func() {},
-		SampleRate:      16000,
-		Bitrate:         0,
-		AudioFormat:     AudioFormatPCMS16LE,
+		TransportFrames:     func([]byte, bool) {},
+		OnError:             func(error) {},
+		OnClose:             func() {},
+		SampleRate:          16000,
+		Bitrate:             0,
+		AudioFormat:         AudioFormatPCMS16LE,
+		DispatchOverflow:    DispatchOverflowBlock,
+		OutboundQueuePolicy: OutboundQueueBlock,
 	}
 }
 
@@ -132,6 +196,33 @@ func WithExpireAt(expireAt time.Time) SessionOption {
 	}
 }
 
+// WithTokenSource makes Init obtain the session token from source instead of exchanging
+// APIKey against the console API, so the API key can stay in a trusted backend.
+// With WithTokenRefresh the source is asked again before the token expires; TTL is ignored.
+func WithTokenSource(source TokenSource) SessionOption {
+	return func(cfg *SessionConfig) {
+		cfg.TokenSource = source
+	}
+}
+
+// WithSessionToken uses a session token minted elsewhere, for example by a backend with
+// ConsoleTokenSource. Init must still be called before Start. expiresAt may be zero when the
+// expiry is unknown. The token cannot be renewed: if the ingress rejects it on reconnect the
+// session closes with ErrSessionTokenNotRenewable.
+func WithSessionToken(token string, expiresAt time.Time) SessionOption {
+	return func(cfg *SessionConfig) {
+		cfg.TokenSource = StaticTokenSource(token, expiresAt)
+	}
+}
+
+// WithModelVersion selects the avatar model version requested when Init mints the session token.
+// Token refreshes request the same version.
+func WithModelVersion(version string) SessionOption {
+	return func(cfg *SessionConfig) {
+		cfg.ModelVersion = version
+	}
+}
+
 // WithSampleRate sets the audio sample rate in Hz.
 func WithSampleRate(sampleRate int) SessionOption {
 	return func(cfg *SessionConfig) {
@@ -185,6 +276,14 @@ func WithTransportFrames(handler func([]byte, bool)) SessionOption {
 	}
 }
 
+// WithOnAnimationFrame registers a handler that receives decoded animation frames.
+// It is invoked right after TransportFrames for the same frame and can be used instead of it.
+func WithOnAnimationFrame(handler func(AnimationFrame)) SessionOption {
+	return func(cfg *SessionConfig) {
+		cfg.OnAnimationFrame = handler
+	}
+}
+
 // WithOnError registers a handler that receives errors emitted by the session.
 func WithOnError(handler func(error)) SessionOption {
 	return func(cfg *SessionConfig) {
@@ -207,6 +306,202 @@ func WithOnClose(handler func()) SessionOption {
 	}
 }
 
+// WithOnStateChange registers a handler that is called on every lifecycle state transition.
+// State changes are delivered in order with the other callbacks and are never dropped.
+func WithOnStateChange(handler func(old, new SessionState)) SessionOption {
+	return func(cfg *SessionConfig) {
+		cfg.OnStateChange = handler
+	}
+}
+
+// WithDispatchQueue bounds the queue of pending TransportFrames, OnError and OnClose callbacks.
+// Callbacks are always delivered one at a time in wire order; the overflow policy decides whether
+// the read loop waits for the handler or drops callbacks when the queue holds size entries.
+// A size of zero or less keeps the queue unbounded.
+func WithDispatchQueue(size int, overflow DispatchOverflowPolicy) SessionOption {
+	return func(cfg *SessionConfig) {
+		cfg.DispatchQueueSize = size
+		cfg.DispatchOverflow = overflow
+	}
+}
+
+// WithEventBuffer sets the capacity of the channel returned by AvatarSession.Events.
+// When the channel is full the oldest buffered event is discarded to make room for the next one.
+func WithEventBuffer(size int) SessionOption {
+	return func(cfg *SessionConfig) {
+		cfg.EventBufferSize = size
+	}
+}
+
+// WithReconnect enables automatic reconnection after the websocket connection drops.
+// The session redials with exponential backoff and repeats the handshake. When the ingress
+// rejects the session token as expired, a replacement is minted with a fresh expiry (the refresh
+// TTL with WithTokenRefresh, otherwise the lifetime of the token minted by Init), or requested
+// from the TokenSource if one is configured. Requests in flight when the connection dropped
+// fail with ErrSessionClosed. Once the policy's attempts are exhausted the final error is
+// reported through OnError and the session closes.
+func WithReconnect(policy ReconnectPolicy) SessionOption {
+	return func(cfg *SessionConfig) {
+		cfg.Reconnect = &policy
+	}
+}
+
+// WithOnReconnect registers a handler that is called after the session reconnects.
+// Reconnect notifications are delivered in order with the other callbacks and are never dropped.
+func WithOnReconnect(handler func(ReconnectEvent)) SessionOption {
+	return func(cfg *SessionConfig) {
+		cfg.OnReconnect = handler
+	}
+}
+
+// WithOnCallbackPanic registers a handler for panics recovered from user callbacks such as
+// TransportFrames, OnAnimationFrame, OnError and OnClose, and from the configured
+// MetricsRecorder, Tracer and TokenSource. A panicking callback never crashes the process or
+// stops later callbacks; without a handler the panic and its stack are logged. A panicking
+// TokenSource fails the token request as if it had returned an error.
+// The handler runs on the goroutine that recovered the panic and must not block.
+func WithOnCallbackPanic(handler func(CallbackPanic)) SessionOption {
+	return func(cfg *SessionConfig) {
+		cfg.OnCallbackPanic = handler
+	}
+}
+
+// WithTokenRefresh enables proactive session token refresh.
+// After Init, a new token is minted policy.Lead before the current one expires, each with a
+// fresh expiry of policy.TTL. Refreshed tokens are used by the next Start or reconnect; an open
+// connection is not affected. Refresh failures are reported through OnError and retried.
+// The refresher stops when the session is closed.
+func WithTokenRefresh(policy TokenRefreshPolicy) SessionOption {
+	return func(cfg *SessionConfig) {
+		cfg.TokenRefresh = &policy
+	}
+}
+
+// WithKeepalive enables websocket keepalive pings and dead-peer detection.
+// A ping is sent every interval and each pong extends the read deadline by interval+timeout.
+// If the server stops answering, the read loop fails with an AvatarSDKError carrying
+// ErrorCodeKeepaliveTimeout, which is reported through OnError before the session closes or
+// reconnects. A timeout of zero or less defaults to interval.
+func WithKeepalive(interval, timeout time.Duration) SessionOption {
+	return func(cfg *SessionConfig) {
+		if timeout <= 0 {
+			timeout = interval
+		}
+		cfg.KeepaliveInterval = interval
+		cfg.KeepaliveTimeout = timeout
+	}
+}
+
+// WithWriteTimeout sets a default write deadline applied to every audio and interrupt message.
+// A context deadline passed to SendAudioContext or InterruptContext takes precedence when it is sooner.
+// A write that times out leaves the websocket unusable, so the connection is dropped and handled
+// like any other disconnect (reconnect if enabled, otherwise OnError and close).
+func WithWriteTimeout(timeout time.Duration) SessionOption {
+	return func(cfg *SessionConfig) {
+		cfg.WriteTimeout = timeout
+	}
+}
+
+// WithOutboundQueue puts a bounded queue in front of the websocket writer.
+// SendAudio and Interrupt then return as soon as their message is queued instead of waiting
+// for the write, and the policy decides what happens once size messages are waiting.
+// Write failures are reported through OnError when the connection drops.
+// Use OutboundQueueStats to watch the queue depth. A size of zero or less disables the queue.
+func WithOutboundQueue(size int, policy OutboundQueuePolicy) SessionOption {
+	return func(cfg *SessionConfig) {
+		cfg.OutboundQueueSize = size
+		cfg.OutboundQueuePolicy = policy
+	}
+}
+
+// WithMaxConcurrentRequests limits how many requests may be in flight at once. A request
+// counts from the time it is started until its final frame, a server error or an interrupt
+// completes it. Starting another request beyond the limit fails with ErrTooManyRequests.
+// A limit of zero or less removes the limit.
+func WithMaxConcurrentRequests(limit int) SessionOption {
+	return func(cfg *SessionConfig) {
+		cfg.MaxConcurrentRequests = limit
+	}
+}
+
+// WithInterruptQuietPeriod sets how long an Interruption waits for further frames of the
+// interrupted request before it completes. Every late frame restarts the period.
+func WithInterruptQuietPeriod(d time.Duration) SessionOption {
+	return func(cfg *SessionConfig) {
+		cfg.InterruptQuietPeriod = d
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to request session tokens from the console API,
+// both in Init and in background token refresh. Use it to configure proxies, custom root CAs,
+// client certificates or timeouts.
+func WithHTTPClient(client *http.Client) SessionOption {
+	return func(cfg *SessionConfig) {
+		cfg.HTTPClient = client
+	}
+}
+
+// WithWebSocketDialer sets the dialer used to connect to the ingress websocket, both in Start
+// and when reconnecting. Use it to configure proxies, TLS, handshake timeouts and buffer sizes.
+func WithWebSocketDialer(dialer *websocket.Dialer) SessionOption {
+	return func(cfg *SessionConfig) {
+		cfg.WebSocketDialer = dialer
+	}
+}
+
+// WithLogger sets the logger for the session's structured records. Connection and request
+// lifecycle events are logged at debug level, reconnects at info level and failures at warn
+// level. Records carry avatar_id, connection_id and req_id attributes where known; API keys,
+// session tokens and egress credentials are never logged. Without a logger the session only
+// reports recovered callback panics and encoder bitrate warnings, through slog.Default().
+func WithLogger(logger *slog.Logger) SessionOption {
+	return func(cfg *SessionConfig) {
+		cfg.Logger = logger
+	}
+}
+
+// WithMaxMessageSize limits the size of inbound websocket messages to n bytes.
+// Reading a larger message stops as soon as it exceeds n, the connection is closed, and a
+// MessageTooLargeError carrying a lower bound of the message size is returned by Start or
+// reported through OnError. It wraps an AvatarSDKError with ErrorCodeProtocolError.
+func WithMaxMessageSize(n int64) SessionOption {
+	return func(cfg *SessionConfig) {
+		cfg.MaxMessageSize = n
+	}
+}
+
+// WithReadBufferSize sets the websocket read buffer size in bytes.
+// It overrides the setting of the dialer passed to WithWebSocketDialer.
+func WithReadBufferSize(n int) SessionOption {
+	return func(cfg *SessionConfig) {
+		cfg.ReadBufferSize = n
+	}
+}
+
+// WithWriteBufferSize sets the websocket write buffer size in bytes.
+// It overrides the setting of the dialer passed to WithWebSocketDialer.
+func WithWriteBufferSize(n int) SessionOption {
+	return func(cfg *SessionConfig) {
+		cfg.WriteBufferSize = n
+	}
+}
+
+// WithMetricsRecorder registers a recorder for request latency and throughput milestones.
+// NewInMemoryMetrics provides a ready-made implementation.
+func WithMetricsRecorder(recorder MetricsRecorder) SessionOption {
+	return func(cfg *SessionConfig) {
+		cfg.Metrics = recorder
+	}
+}
+
+// WithTracer registers a tracer for the token exchange, websocket dial, session handshake
+// and request spans.
+func WithTracer(tracer Tracer) SessionOption {
+	return func(cfg *SessionConfig) {
+		cfg.Tracer = tracer
+	}
+}
+
 // WithConsoleEndpointURL overrides the default console endpoint URL used by the session.
 func WithConsoleEndpointURL(endpointURL string) SessionOption {
 	return func(cfg *SessionConfig) {
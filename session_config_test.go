@@ -1,9 +1,15 @@
 package avatarsdkgo
 
 import (
+	"context"
 	"errors"
+	"io"
+	"log/slog"
+	"net/http"
 	"testing"
 	"time"
+
+	"github.com/gorilla/websocket"
 )
 
 func TestSessionOptionOverrides(t *testing.T) {
@@ -14,6 +20,7 @@ func TestSessionOptionOverrides(t *testing.T) {
 	var onErrorCalled bool
 	var onCloseCalled bool
 	var encodedAudioCalled bool
+	var animationFrameCalled bool
 
 	frameHandler := func(data []byte, end bool) {
 		framesCalled = true
@@ -22,6 +29,17 @@ func TestSessionOptionOverrides(t *testing.T) {
 		}
 	}
 
+	animationFrameHandler := func(frame AnimationFrame) {
+		animationFrameCalled = frame.ReqID == "req-123"
+	}
+
+	httpClient := &http.Client{Timeout: time.Second}
+	dialer := &websocket.Dialer{HandshakeTimeout: time.Second}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	metrics := NewInMemoryMetrics()
+	tracer := &recordingTracer{}
+	var callbackPanic string
+
 	errSentinel := errors.New("boom")
 	errorHandler := func(err error) {
 		onErrorCalled = err == errSentinel
@@ -49,8 +67,29 @@ func TestSessionOptionOverrides(t *testing.T) {
 		}),
 		WithOnEncodedAudio(encodedAudioHandler),
 		WithTransportFrames(frameHandler),
+		WithOnAnimationFrame(animationFrameHandler),
 		WithOnError(errorHandler),
 		WithOnClose(closeHandler),
+		WithDispatchQueue(64, DispatchOverflowDropOldest),
+		WithOutboundQueue(32, OutboundQueueDropOldestAudio),
+		WithEventBuffer(16),
+		WithInterruptQuietPeriod(250 * time.Millisecond),
+		WithMaxConcurrentRequests(4),
+		WithHTTPClient(httpClient),
+		WithWebSocketDialer(dialer),
+		WithMaxMessageSize(1 << 20),
+		WithModelVersion("v2.1"),
+		WithSessionToken("pre-minted-token", expireAt),
+		WithReadBufferSize(8192),
+		WithWriteBufferSize(4096),
+		WithLogger(logger),
+		WithMetricsRecorder(metrics),
+		WithTracer(tracer),
+		WithReconnect(ReconnectPolicy{MaxAttempts: 3, InitialBackoff: time.Second}),
+		WithOnReconnect(func(ReconnectEvent) {}),
+		WithOnCallbackPanic(func(p CallbackPanic) { callbackPanic = p.Callback }),
+		WithOnStateChange(func(SessionState, SessionState) {}),
+		WithTokenRefresh(TokenRefreshPolicy{Lead: time.Minute, TTL: time.Hour}),
 		WithConsoleEndpointURL("https://console.test"),
 		WithIngressEndpointURL("https://ingress.test"),
 		WithLiveKitEgress(&LiveKitEgressConfig{
@@ -102,6 +141,67 @@ func TestSessionOptionOverrides(t *testing.T) {
 	if cfg.OggOpusEncoder.Application != OggOpusApplicationVoIP {
 		t.Fatalf("expected Application to be %q, got %q", OggOpusApplicationVoIP, cfg.OggOpusEncoder.Application)
 	}
+	if cfg.DispatchQueueSize != 64 {
+		t.Fatalf("expected DispatchQueueSize to be 64, got %d", cfg.DispatchQueueSize)
+	}
+	if cfg.DispatchOverflow != DispatchOverflowDropOldest {
+		t.Fatalf("expected DispatchOverflow to be %q, got %q", DispatchOverflowDropOldest, cfg.DispatchOverflow)
+	}
+	if cfg.HTTPClient != httpClient {
+		t.Fatal("expected HTTPClient to be set")
+	}
+	if cfg.WebSocketDialer != dialer {
+		t.Fatal("expected WebSocketDialer to be set")
+	}
+	if token, tokenExpiresAt, err := cfg.TokenSource.Token(context.Background()); err != nil || token != "pre-minted-token" || !tokenExpiresAt.Equal(expireAt) {
+		t.Fatalf("expected a static TokenSource, got %q, %v, %v", token, tokenExpiresAt, err)
+	}
+	if cfg.ModelVersion != "v2.1" {
+		t.Fatalf("expected ModelVersion to be v2.1, got %q", cfg.ModelVersion)
+	}
+	if cfg.MaxMessageSize != 1<<20 || cfg.ReadBufferSize != 8192 || cfg.WriteBufferSize != 4096 {
+		t.Fatalf("expected message size and buffer options to be set, got %d/%d/%d", cfg.MaxMessageSize, cfg.ReadBufferSize, cfg.WriteBufferSize)
+	}
+	if cfg.Logger != logger {
+		t.Fatal("expected Logger to be set")
+	}
+	if cfg.Metrics != metrics {
+		t.Fatal("expected Metrics to be set")
+	}
+	if cfg.Tracer != tracer {
+		t.Fatal("expected Tracer to be set")
+	}
+	cfg.OnCallbackPanic(CallbackPanic{Callback: "OnError"})
+	if callbackPanic != "OnError" {
+		t.Fatal("expected OnCallbackPanic to be set")
+	}
+	if cfg.MaxConcurrentRequests != 4 {
+		t.Fatalf("expected MaxConcurrentRequests to be 4, got %d", cfg.MaxConcurrentRequests)
+	}
+	if cfg.InterruptQuietPeriod != 250*time.Millisecond {
+		t.Fatalf("expected InterruptQuietPeriod to be 250ms, got %v", cfg.InterruptQuietPeriod)
+	}
+	if cfg.EventBufferSize != 16 {
+		t.Fatalf("expected EventBufferSize to be 16, got %d", cfg.EventBufferSize)
+	}
+	if cfg.OutboundQueueSize != 32 {
+		t.Fatalf("expected OutboundQueueSize to be 32, got %d", cfg.OutboundQueueSize)
+	}
+	if cfg.OutboundQueuePolicy != OutboundQueueDropOldestAudio {
+		t.Fatalf("expected OutboundQueuePolicy to be %q, got %q", OutboundQueueDropOldestAudio, cfg.OutboundQueuePolicy)
+	}
+	if cfg.Reconnect == nil || cfg.Reconnect.MaxAttempts != 3 || cfg.Reconnect.InitialBackoff != time.Second {
+		t.Fatalf("expected Reconnect policy to be set, got %+v", cfg.Reconnect)
+	}
+	if cfg.OnReconnect == nil {
+		t.Fatal("OnReconnect handler should not be nil")
+	}
+	if cfg.OnStateChange == nil {
+		t.Fatal("OnStateChange handler should not be nil")
+	}
+	if cfg.TokenRefresh == nil || cfg.TokenRefresh.Lead != time.Minute || cfg.TokenRefresh.TTL != time.Hour {
+		t.Fatalf("expected TokenRefresh policy to be set, got %+v", cfg.TokenRefresh)
+	}
 	if cfg.ConsoleEndpointURL != "https://console.test" {
 		t.Fatalf("expected ConsoleEndpointURL to be set, got %q", cfg.ConsoleEndpointURL)
 	}
@@ -135,6 +235,14 @@ func TestSessionOptionOverrides(t *testing.T) {
 		t.Fatal("TransportFrames handler was not invoked")
 	}
 
+	if cfg.OnAnimationFrame == nil {
+		t.Fatal("OnAnimationFrame handler should not be nil")
+	}
+	cfg.OnAnimationFrame(AnimationFrame{ReqID: "req-123"})
+	if !animationFrameCalled {
+		t.Fatal("OnAnimationFrame handler was not invoked")
+	}
+
 	if cfg.OnError == nil {
 		t.Fatal("OnError handler should not be nil")
 	}
@@ -187,12 +295,39 @@ func TestSessionOptionDefaults(t *testing.T) {
 	if cfg.OnEncodedAudio != nil {
 		t.Fatal("expected default OnEncodedAudio to be nil")
 	}
+	if cfg.OnAnimationFrame != nil {
+		t.Fatal("expected default OnAnimationFrame to be nil")
+	}
 	if cfg.UseQueryAuth {
 		t.Fatal("expected default UseQueryAuth to be false")
 	}
 	if cfg.LiveKitEgress != nil {
 		t.Fatal("expected default LiveKitEgress to be nil")
 	}
+	if cfg.DispatchQueueSize != 0 {
+		t.Fatalf("expected default DispatchQueueSize to be 0, got %d", cfg.DispatchQueueSize)
+	}
+	if cfg.DispatchOverflow != DispatchOverflowBlock {
+		t.Fatalf("expected default DispatchOverflow to be %q, got %q", DispatchOverflowBlock, cfg.DispatchOverflow)
+	}
+	if cfg.Reconnect != nil {
+		t.Fatal("expected default Reconnect to be nil")
+	}
+	if cfg.TokenRefresh != nil {
+		t.Fatal("expected default TokenRefresh to be nil")
+	}
+	if cfg.KeepaliveInterval != 0 {
+		t.Fatalf("expected keepalive to be disabled by default, got interval %v", cfg.KeepaliveInterval)
+	}
+	if cfg.WriteTimeout != 0 {
+		t.Fatalf("expected no default WriteTimeout, got %v", cfg.WriteTimeout)
+	}
+	if cfg.OutboundQueueSize != 0 {
+		t.Fatalf("expected the outbound queue to be disabled by default, got size %d", cfg.OutboundQueueSize)
+	}
+	if cfg.OutboundQueuePolicy != OutboundQueueBlock {
+		t.Fatalf("expected default OutboundQueuePolicy to be %q, got %q", OutboundQueueBlock, cfg.OutboundQueuePolicy)
+	}
 
 	// Ensure default handlers do not panic.
 	cfg.TransportFrames([]byte("noop"), false)
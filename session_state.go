@@ -0,0 +1,115 @@
+package avatarsdkgo
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SessionState identifies where an AvatarSession is in its lifecycle.
+//
+// A session moves from idle to initialized on Init, through connecting to ready on Start,
+// and between ready and reconnecting when automatic reconnect is enabled. Shutdown moves a
+// ready session to draining. Close moves any state to closed, which is terminal.
+type SessionState string
+
+const (
+	// SessionStateIdle is the state of a new session before Init.
+	SessionStateIdle SessionState = "idle"
+	// SessionStateInitialized means a session token is available and Start may be called.
+	SessionStateInitialized SessionState = "initialized"
+	// SessionStateConnecting means Start is dialing the ingress and performing the handshake.
+	SessionStateConnecting SessionState = "connecting"
+	// SessionStateReady means the connection is established and audio can be sent.
+	SessionStateReady SessionState = "ready"
+	// SessionStateDraining means the session no longer accepts new audio and is waiting for
+	// outstanding requests before it closes.
+	SessionStateDraining SessionState = "draining"
+	// SessionStateReconnecting means the connection dropped and the session is redialing.
+	SessionStateReconnecting SessionState = "reconnecting"
+	// SessionStateClosed means the session has been closed and cannot be used again.
+	SessionStateClosed SessionState = "closed"
+)
+
+var (
+	// ErrSessionNotInitialized is reported when Start is called before Init obtained a session token.
+	ErrSessionNotInitialized = errors.New("session not initialized")
+	// ErrSessionAlreadyStarted is reported when Start is called on a session that is already started.
+	ErrSessionAlreadyStarted = errors.New("session already started")
+	// ErrSessionDraining is reported when a new request is started while Shutdown is draining the session.
+	ErrSessionDraining = errors.New("session is draining")
+	// ErrSessionNotReady is reported when audio is sent while the session has no established connection.
+	ErrSessionNotReady = errors.New("websocket connection is not established")
+)
+
+// StateError reports a call that is not valid in the session's current state.
+// Err is one of ErrSessionNotInitialized, ErrSessionAlreadyStarted, ErrSessionNotReady,
+// ErrSessionDraining or ErrSessionClosed, so callers can match it with errors.Is.
+type StateError struct {
+	Op    string
+	State SessionState
+	Err   error
+}
+
+// Error implements the error interface.
+func (e *StateError) Error() string {
+	return fmt.Sprintf("%s: %v (state %s)", e.Op, e.Err, e.State)
+}
+
+// Unwrap returns the underlying sentinel error.
+func (e *StateError) Unwrap() error {
+	return e.Err
+}
+
+// State returns the session's current lifecycle state.
+func (s *AvatarSession) State() SessionState {
+	if s == nil {
+		return SessionStateClosed
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// setStateLocked moves the session to next and queues OnStateChange and a StateChangeEvent.
+// Callers must hold s.mu.
+func (s *AvatarSession) setStateLocked(next SessionState) {
+	previous := s.state
+	if previous == next {
+		return
+	}
+	s.state = next
+
+	if s.config == nil || (s.config.OnStateChange == nil && s.events == nil) {
+		return
+	}
+	handler := s.config.OnStateChange
+	// Lifecycle notifications bypass the queue bound so they are never dropped and
+	// never block while s.mu is held.
+	s.dispatcherLocked().enqueue(func() {
+		if handler != nil {
+			s.invokeCallback("OnStateChange", func() { handler(previous, next) })
+		}
+		s.publish(StateChangeEvent{Old: previous, New: next})
+	})
+}
+
+// stateErrorLocked builds a StateError for op in the current state.
+// Callers must hold s.mu.
+func (s *AvatarSession) stateErrorLocked(op string, err error) *StateError {
+	return &StateError{Op: op, State: s.state, Err: err}
+}
+
+// checkReadyLocked reports whether new audio may be sent in the current state.
+// Callers must hold s.mu.
+func (s *AvatarSession) checkReadyLocked(op string) error {
+	switch s.state {
+	case SessionStateReady:
+		return nil
+	case SessionStateDraining:
+		return s.stateErrorLocked(op, ErrSessionDraining)
+	case SessionStateClosed:
+		return s.stateErrorLocked(op, ErrSessionClosed)
+	default:
+		return s.stateErrorLocked(op, ErrSessionNotReady)
+	}
+}
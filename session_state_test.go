@@ -0,0 +1,219 @@
+package avatarsdkgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stateRecorder collects OnStateChange and OnClose notifications in delivery order.
+type stateRecorder struct {
+	mu     sync.Mutex
+	events []string
+	closed chan struct{}
+}
+
+func newStateRecorder() *stateRecorder {
+	return &stateRecorder{closed: make(chan struct{})}
+}
+
+func (r *stateRecorder) options() []SessionOption {
+	return []SessionOption{
+		WithOnStateChange(func(old, new SessionState) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.events = append(r.events, fmt.Sprintf("%s->%s", old, new))
+		}),
+		WithOnClose(func() {
+			r.mu.Lock()
+			r.events = append(r.events, "onclose")
+			r.mu.Unlock()
+			close(r.closed)
+		}),
+	}
+}
+
+func (r *stateRecorder) waitClosed(t *testing.T) []string {
+	t.Helper()
+	select {
+	case <-r.closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for OnClose")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+func TestSessionStateLifecycle(t *testing.T) {
+	console, _ := newTokenConsole(t, func(int32) int { return http.StatusOK })
+	ingress := newFakeIngress(t)
+	recorder := newStateRecorder()
+
+	session := NewAvatarSession(append([]SessionOption{
+		WithAPIKey("api-key"),
+		WithExpireAt(time.Now().Add(time.Hour)),
+		WithConsoleEndpointURL(console.URL),
+		WithAvatarID("avatar-123"),
+		WithAppID("app-123"),
+		WithIngressEndpointURL(ingress.url()),
+	}, recorder.options()...)...)
+
+	if state := session.State(); state != SessionStateIdle {
+		t.Fatalf("expected idle state, got %q", state)
+	}
+	if err := session.Init(context.Background()); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	if state := session.State(); state != SessionStateInitialized {
+		t.Fatalf("expected initialized state, got %q", state)
+	}
+	if _, err := session.Start(context.Background()); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	if state := session.State(); state != SessionStateReady {
+		t.Fatalf("expected ready state, got %q", state)
+	}
+	if err := session.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if state := session.State(); state != SessionStateClosed {
+		t.Fatalf("expected closed state, got %q", state)
+	}
+
+	expected := []string{
+		"idle->initialized",
+		"initialized->connecting",
+		"connecting->ready",
+		"ready->closed",
+		"onclose",
+	}
+	if got := recorder.waitClosed(t); strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected events %v, got %v", expected, got)
+	}
+}
+
+func TestSessionStateRejectsIllegalCalls(t *testing.T) {
+	ingress := newFakeIngress(t)
+	recorder := newStateRecorder()
+	session := NewAvatarSession(append([]SessionOption{
+		WithAvatarID("avatar-123"),
+		WithAppID("app-123"),
+		WithIngressEndpointURL(ingress.url()),
+	}, recorder.options()...)...)
+
+	assertStateError := func(op string, err error, target error, state SessionState) {
+		t.Helper()
+		var stateErr *StateError
+		if !errors.As(err, &stateErr) {
+			t.Fatalf("%s: expected StateError, got %T: %v", op, err, err)
+		}
+		if !errors.Is(err, target) {
+			t.Fatalf("%s: expected %v, got %v", op, target, err)
+		}
+		if stateErr.State != state {
+			t.Fatalf("%s: expected state %q, got %q", op, state, stateErr.State)
+		}
+	}
+
+	_, err := session.SendAudio([]byte{0x01}, true)
+	assertStateError("SendAudio before Start", err, ErrSessionNotReady, SessionStateIdle)
+
+	_, err = session.Start(context.Background())
+	assertStateError("Start before Init", err, ErrSessionNotInitialized, SessionStateIdle)
+
+	session.mu.Lock()
+	session.sessionToken = "session-token-123"
+	session.mu.Unlock()
+	if _, err := session.Start(context.Background()); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	_, err = session.Start(context.Background())
+	assertStateError("second Start", err, ErrSessionAlreadyStarted, SessionStateReady)
+
+	if err := session.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if err := session.Close(); err != nil {
+		t.Fatalf("second Close returned error: %v", err)
+	}
+
+	_, err = session.SendAudio([]byte{0x01}, true)
+	assertStateError("SendAudio after Close", err, ErrSessionClosed, SessionStateClosed)
+	_, err = session.BeginRequest()
+	assertStateError("BeginRequest after Close", err, ErrSessionClosed, SessionStateClosed)
+	_, err = session.Start(context.Background())
+	assertStateError("Start after Close", err, ErrSessionClosed, SessionStateClosed)
+	err = session.Init(context.Background())
+	assertStateError("Init after Close", err, ErrSessionClosed, SessionStateClosed)
+
+	events := recorder.waitClosed(t)
+	if events[len(events)-1] != "onclose" {
+		t.Fatalf("expected OnClose last, got %v", events)
+	}
+	time.Sleep(20 * time.Millisecond)
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if len(recorder.events) != len(events) {
+		t.Fatalf("expected second Close to be a no-op, got events %v", recorder.events)
+	}
+}
+
+func TestSessionStateStartFailureRestoresState(t *testing.T) {
+	ingress := newFakeIngressWithAuth(t, func(*http.Request) int { return http.StatusUnauthorized })
+	session := NewAvatarSession(
+		WithAvatarID("avatar-123"),
+		WithAppID("app-123"),
+		WithIngressEndpointURL(ingress.url()),
+	)
+	session.mu.Lock()
+	session.sessionToken = "session-token-123"
+	session.state = SessionStateInitialized
+	session.mu.Unlock()
+
+	if _, err := session.Start(context.Background()); err == nil {
+		t.Fatal("expected Start to fail")
+	}
+	if state := session.State(); state != SessionStateInitialized {
+		t.Fatalf("expected state to return to initialized, got %q", state)
+	}
+}
+
+func TestSessionStateReconnecting(t *testing.T) {
+	ingress := newFakeIngress(t)
+	recorder := newStateRecorder()
+	reconnected := make(chan struct{})
+	session := startTestSession(t, ingress, append(recorder.options(),
+		WithReconnect(ReconnectPolicy{InitialBackoff: 10 * time.Millisecond}),
+		WithOnReconnect(func(ReconnectEvent) { close(reconnected) }),
+	)...)
+
+	first := ingress.accept(t)
+	_ = first.conn.Close()
+
+	select {
+	case <-reconnected:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for reconnect")
+	}
+	if err := session.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	expected := []string{
+		"initialized->connecting",
+		"connecting->ready",
+		"ready->reconnecting",
+		"reconnecting->ready",
+		"ready->closed",
+		"onclose",
+	}
+	if got := recorder.waitClosed(t); strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected events %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,92 @@
+package avatarsdkgo
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// SessionStats is a snapshot of what a session has done since it was created.
+type SessionStats struct {
+	// ConnectionID is the ID of the active connection, or empty when not connected.
+	ConnectionID string
+	// StartedAt is when Start established the first connection, or the zero time before that.
+	StartedAt time.Time
+
+	RequestsStarted     uint64
+	RequestsCompleted   uint64 // finished with their final frame
+	RequestsInterrupted uint64 // stopped with Interrupt or InterruptRequest
+	RequestsFailed      uint64 // ended by a server error, a send failure or a closed connection
+
+	// AudioBytesIn counts audio bytes passed to the session for sending, before encoding.
+	AudioBytesIn uint64
+	// EncodedBytesOut counts audio payload bytes written to the connection, after encoding.
+	EncodedBytesOut uint64
+
+	FramesReceived uint64
+	// LastFrameAt is the ReceivedAt time of the latest delivered frame.
+	LastFrameAt time.Time
+
+	// Reconnects counts connections replaced after the connection was lost.
+	Reconnects uint64
+}
+
+// sessionStats accumulates SessionStats. It has its own lock so hot paths such as the
+// read loop do not contend with s.mu.
+type sessionStats struct {
+	mu   sync.Mutex
+	data SessionStats
+}
+
+func (st *sessionStats) update(fn func(*SessionStats)) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	fn(&st.data)
+}
+
+// Stats returns a snapshot of the session's counters. It is safe to call concurrently,
+// including from a health endpoint while the session is streaming.
+func (s *AvatarSession) Stats() SessionStats {
+	if s == nil {
+		return SessionStats{}
+	}
+	s.stats.mu.Lock()
+	stats := s.stats.data
+	s.stats.mu.Unlock()
+
+	s.mu.Lock()
+	if s.conn != nil {
+		stats.ConnectionID = s.connectionID
+	}
+	s.mu.Unlock()
+	return stats
+}
+
+// recordSessionStarted stores the time the first connection was established.
+func (s *AvatarSession) recordSessionStarted() {
+	now := s.timeNow()
+	s.stats.update(func(stats *SessionStats) {
+		if stats.StartedAt.IsZero() {
+			stats.StartedAt = now
+		}
+	})
+}
+
+// recordReconnect counts a replaced connection.
+func (s *AvatarSession) recordReconnect() {
+	s.stats.update(func(stats *SessionStats) {
+		stats.Reconnects++
+	})
+}
+
+// countRequestFinished classifies a finished request by its error.
+func (stats *SessionStats) countRequestFinished(err error) {
+	switch {
+	case err == nil:
+		stats.RequestsCompleted++
+	case errors.Is(err, ErrRequestInterrupted):
+		stats.RequestsInterrupted++
+	default:
+		stats.RequestsFailed++
+	}
+}
@@ -0,0 +1,158 @@
+package avatarsdkgo
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	message "github.com/spatialwalk/avatar-sdk-go/proto/generated"
+)
+
+func TestAvatarSessionStats(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, time.October, 27, 14, 30, 34, 0, time.UTC)}
+	startedAt := clock.Now()
+
+	ingress := newFakeIngress(t)
+	session := NewAvatarSession(
+		WithAvatarID("avatar-123"),
+		WithAppID("app-123"),
+		WithIngressEndpointURL(ingress.url()),
+		WithInterruptQuietPeriod(10*time.Millisecond),
+	)
+	session.sessionToken = "session-token-123"
+	session.now = clock.Now
+	if got := session.Stats(); got != (SessionStats{}) {
+		t.Fatalf("expected empty stats before Start, got %+v", got)
+	}
+	connectionID, err := session.Start(context.Background())
+	if err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	defer session.Close() // nolint:errcheck
+	serverConn := ingress.accept(t)
+
+	// Poll concurrently like a health endpoint would.
+	stop := make(chan struct{})
+	var polling sync.WaitGroup
+	polling.Add(1)
+	go func() {
+		defer polling.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				_ = session.Stats()
+			}
+		}
+	}()
+
+	completed, err := session.BeginRequest()
+	if err != nil {
+		t.Fatalf("BeginRequest returned error: %v", err)
+	}
+	if err := completed.Write(make([]byte, 640)); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	if err := completed.End(); err != nil {
+		t.Fatalf("End returned error: %v", err)
+	}
+	failed, err := session.BeginRequest()
+	if err != nil {
+		t.Fatalf("BeginRequest returned error: %v", err)
+	}
+	if err := failed.Write(make([]byte, 320)); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	interrupted, err := session.BeginRequest()
+	if err != nil {
+		t.Fatalf("BeginRequest returned error: %v", err)
+	}
+	if err := interrupted.Write(make([]byte, 160)); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+
+	clock.advance(time.Second)
+	lastFrameAt := clock.Now()
+	serverConn.send(t, animationMessage(serverConn.connectionID, completed.ID(), false))
+	serverConn.send(t, animationMessage(serverConn.connectionID, completed.ID(), true))
+	serverConn.send(t, &message.Message{
+		Type: message.MessageType_MESSAGE_SERVER_ERROR,
+		Data: &message.Message_ServerError{
+			ServerError: &message.ServerError{
+				ConnectionId: serverConn.connectionID,
+				ReqId:        failed.ID(),
+				Code:         400,
+				Message:      "bad audio",
+			},
+		},
+	})
+	interruption, err := session.InterruptRequest(interrupted.ID())
+	if err != nil {
+		t.Fatalf("InterruptRequest returned error: %v", err)
+	}
+	if err := interruption.Wait(context.Background()); err != nil {
+		t.Fatalf("interruption Wait returned error: %v", err)
+	}
+	for _, req := range []*Request{completed, failed, interrupted} {
+		_ = req.Wait(context.Background())
+	}
+	close(stop)
+	polling.Wait()
+
+	want := SessionStats{
+		ConnectionID:        connectionID,
+		StartedAt:           startedAt,
+		RequestsStarted:     3,
+		RequestsCompleted:   1,
+		RequestsInterrupted: 1,
+		RequestsFailed:      1,
+		AudioBytesIn:        640 + 320 + 160,
+		EncodedBytesOut:     640 + 320 + 160,
+		FramesReceived:      2,
+		LastFrameAt:         lastFrameAt,
+	}
+	if got := session.Stats(); got != want {
+		t.Fatalf("expected stats %+v, got %+v", want, got)
+	}
+}
+
+func TestAvatarSessionStatsCountsReconnects(t *testing.T) {
+	reconnects := make(chan ReconnectEvent, 1)
+
+	ingress := newFakeIngress(t)
+	session := startTestSession(t, ingress,
+		WithReconnect(ReconnectPolicy{InitialBackoff: 10 * time.Millisecond}),
+		WithOnReconnect(func(event ReconnectEvent) { reconnects <- event }),
+	)
+	defer session.Close() // nolint:errcheck
+	first := ingress.accept(t)
+	startedAt := session.Stats().StartedAt
+
+	_ = first.conn.Close()
+	var event ReconnectEvent
+	select {
+	case event = <-reconnects:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for reconnect")
+	}
+
+	stats := session.Stats()
+	if stats.Reconnects != 1 {
+		t.Fatalf("expected 1 reconnect, got %d", stats.Reconnects)
+	}
+	if stats.ConnectionID != event.ConnectionID {
+		t.Fatalf("expected connection ID %q, got %q", event.ConnectionID, stats.ConnectionID)
+	}
+	if startedAt.IsZero() || !stats.StartedAt.Equal(startedAt) {
+		t.Fatalf("expected StartedAt to stay %v across reconnects, got %v", startedAt, stats.StartedAt)
+	}
+
+	if err := session.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if got := session.Stats(); got.ConnectionID != "" || got.Reconnects != 1 {
+		t.Fatalf("expected counters to survive Close without a connection ID, got %+v", got)
+	}
+}
@@ -0,0 +1,228 @@
+package avatarsdkgo
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultTokenRefreshLead          = 30 * time.Second
+	defaultTokenRefreshRetryInterval = 5 * time.Second
+)
+
+// TokenRefreshPolicy configures proactive session token refresh.
+// Zero fields select the defaults documented on each field.
+type TokenRefreshPolicy struct {
+	// Lead is how long before the current token expires a new one is minted. Defaults to 30s.
+	// It is capped at half the token lifetime so refreshes cannot run back to back.
+	Lead time.Duration
+	// TTL is the lifetime requested for each refreshed token. Defaults to the lifetime of the
+	// token minted by Init, i.e. ExpireAt minus the time Init was called.
+	TTL time.Duration
+	// RetryInterval is the delay before retrying a failed refresh. Defaults to 5s.
+	RetryInterval time.Duration
+}
+
+// tokenRefresher is the background goroutine that keeps the session token fresh.
+type tokenRefresher struct {
+	policy TokenRefreshPolicy
+	cancel context.CancelFunc
+	reset  chan struct{}
+}
+
+func (p TokenRefreshPolicy) withDefaults(initialLifetime time.Duration) TokenRefreshPolicy {
+	if p.TTL <= 0 {
+		p.TTL = initialLifetime
+	}
+	if p.TTL <= 0 {
+		// ExpireAt was already in the past; fall back to something that cannot spin.
+		p.TTL = 2 * defaultTokenRefreshLead
+	}
+	if p.Lead <= 0 {
+		p.Lead = defaultTokenRefreshLead
+	}
+	if p.Lead > p.TTL/2 {
+		p.Lead = p.TTL / 2
+	}
+	if p.RetryInterval <= 0 {
+		p.RetryInterval = defaultTokenRefreshRetryInterval
+	}
+	return p
+}
+
+// wake makes the refresher re-read the token expiry, e.g. after Init minted a new token.
+func (r *tokenRefresher) wake() {
+	select {
+	case r.reset <- struct{}{}:
+	default:
+	}
+}
+
+// startTokenRefresh launches the refresher goroutine. Callers must hold s.mu.
+func (s *AvatarSession) startTokenRefresh(policy TokenRefreshPolicy, initialLifetime time.Duration) *tokenRefresher {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &tokenRefresher{
+		policy: policy.withDefaults(initialLifetime),
+		cancel: cancel,
+		reset:  make(chan struct{}, 1),
+	}
+	go s.runTokenRefresh(ctx, r)
+	return r
+}
+
+func (s *AvatarSession) runTokenRefresh(ctx context.Context, r *tokenRefresher) {
+	for {
+		s.mu.Lock()
+		expiresAt := s.tokenExpiresAt
+		s.mu.Unlock()
+
+		timer := time.NewTimer(expiresAt.Sub(s.timeNow()) - r.policy.Lead)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-r.reset:
+			timer.Stop()
+			continue
+		case <-timer.C:
+		}
+
+		if err := s.refreshSessionToken(ctx, r, ""); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			s.logger().Warn("session token refresh failed", "error", err)
+			s.reportRefreshError(r, err)
+			if !sleepContext(ctx, r.policy.RetryInterval) {
+				return
+			}
+		}
+	}
+}
+
+// refreshSessionToken mints a token with a fresh expiry, or asks the configured TokenSource for
+// one, and makes it available to the next Start or reconnect. The connection that is already
+// open keeps working with the old token. rejected is the token the ingress refused, if any;
+// a source that returns it again fails with ErrSessionTokenNotRenewable.
+func (s *AvatarSession) refreshSessionToken(ctx context.Context, r *tokenRefresher, rejected string) error {
+	var (
+		sessionToken string
+		expireAt     time.Time
+		err          error
+	)
+	if s.config.TokenSource != nil {
+		s.mu.Lock()
+		current := s.tokenExpiresAt
+		s.mu.Unlock()
+
+		sessionToken, expireAt, err = s.tokenFromSource(ctx)
+		switch {
+		case err != nil:
+		case rejected != "" && sessionToken == rejected:
+			err = errRejectedTokenReturned
+		case !expireAt.After(current):
+			// Refreshing again right away would spin; wait for the source to rotate its token.
+			err = fmt.Errorf("token source returned a token expiring at %v, not after the current one", expireAt)
+		}
+	} else {
+		expireAt = s.timeNow().Add(r.policy.TTL)
+		sessionToken, err = s.requestSessionToken(ctx, expireAt)
+	}
+	if err != nil {
+		return fmt.Errorf("refresh session token: %w", err)
+	}
+
+	s.mu.Lock()
+	if s.refresher == r {
+		s.sessionToken = sessionToken
+		s.tokenExpiresAt = expireAt
+	}
+	s.mu.Unlock()
+	s.logger().Debug("session token refreshed", "expire_at", expireAt)
+	return nil
+}
+
+// renewSessionToken mints a replacement for a token the server rejected. The replacement gets a
+// fresh expiry: the refresh TTL with token refresh enabled, otherwise the lifetime of the token
+// minted by Init counted from now, since the configured ExpireAt may already have passed.
+// A TokenSource that implements TokenInvalidator is told to drop the rejected token first; if
+// the source returns the rejected token again the error matches ErrSessionTokenNotRenewable.
+func (s *AvatarSession) renewSessionToken(ctx context.Context) error {
+	s.mu.Lock()
+	r := s.refresher
+	lifetime := s.tokenLifetime
+	rejected := s.sessionToken
+	s.mu.Unlock()
+
+	if s.config.TokenSource != nil {
+		s.invalidateSourceToken()
+	}
+	if r != nil {
+		if err := s.refreshSessionToken(ctx, r, rejected); err != nil {
+			return err
+		}
+		r.wake()
+		return nil
+	}
+	if s.config.TokenSource != nil {
+		if err := s.Init(ctx); err != nil {
+			return err
+		}
+		s.mu.Lock()
+		renewed := s.sessionToken
+		s.mu.Unlock()
+		if renewed == rejected {
+			return fmt.Errorf("renew session token: %w", errRejectedTokenReturned)
+		}
+		return nil
+	}
+
+	if lifetime <= 0 {
+		// ExpireAt was already in the past at Init; fall back to the console source default.
+		lifetime = defaultConsoleTokenTTL
+	}
+	expireAt := s.timeNow().Add(lifetime)
+	sessionToken, err := s.requestSessionToken(ctx, expireAt)
+	if err != nil {
+		return fmt.Errorf("renew session token: %w", err)
+	}
+
+	s.mu.Lock()
+	if s.state == SessionStateClosed {
+		err := s.stateErrorLocked("renew session token", ErrSessionClosed)
+		s.mu.Unlock()
+		return err
+	}
+	s.sessionToken = sessionToken
+	s.tokenExpiresAt = expireAt
+	s.mu.Unlock()
+	s.logger().Debug("session token renewed", "expire_at", expireAt)
+	return nil
+}
+
+// reportRefreshError delivers a failed background refresh through OnError and Events.
+func (s *AvatarSession) reportRefreshError(r *tokenRefresher, err error) {
+	s.mu.Lock()
+	if s.refresher != r {
+		s.mu.Unlock()
+		return
+	}
+	d := s.dispatcherLocked()
+	s.mu.Unlock()
+
+	d.dispatch(func() {
+		if s.config.OnError != nil {
+			s.invokeCallback("OnError", func() { s.config.OnError(err) })
+		}
+		s.publish(ErrorEvent{Err: err})
+	})
+}
+
+// stopTokenRefreshLocked stops the refresher, if any. Callers must hold s.mu.
+func (s *AvatarSession) stopTokenRefreshLocked() {
+	if s.refresher != nil {
+		s.refresher.cancel()
+		s.refresher = nil
+	}
+}
@@ -0,0 +1,164 @@
+package avatarsdkgo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTokenConsole(t *testing.T, status func(n int32) int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var issued atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := issued.Add(1)
+		if code := status(n); code != http.StatusOK {
+			w.WriteHeader(code)
+			return
+		}
+		var payload sessionTokenRequest
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("failed to decode request payload: %v", err)
+		}
+		if payload.ExpireAt == 0 {
+			t.Errorf("expected expireAt in token request")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(sessionTokenResponse{SessionToken: fmt.Sprintf("session-token-%d", n)})
+	}))
+	t.Cleanup(server.Close)
+	return server, &issued
+}
+
+func waitForCount(t *testing.T, counter *atomic.Int32, want int32) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for counter.Load() < want {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for count %d, got %d", want, counter.Load())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTokenRefreshMintsBeforeExpiry(t *testing.T) {
+	console, issued := newTokenConsole(t, func(int32) int { return http.StatusOK })
+
+	dialTokens := make(chan string, 1)
+	ingress := newFakeIngressWithAuth(t, func(r *http.Request) int {
+		dialTokens <- r.Header.Get("X-Session-Key")
+		return http.StatusOK
+	})
+
+	expireAt := time.Now().Add(300 * time.Millisecond)
+	session := NewAvatarSession(
+		WithAPIKey("api-key"),
+		WithExpireAt(expireAt),
+		WithConsoleEndpointURL(console.URL),
+		WithAvatarID("avatar-123"),
+		WithAppID("app-123"),
+		WithIngressEndpointURL(ingress.url()),
+		WithTokenRefresh(TokenRefreshPolicy{Lead: 150 * time.Millisecond, TTL: 300 * time.Millisecond}),
+	)
+
+	if got := session.TokenExpiresAt(); !got.IsZero() {
+		t.Fatalf("expected zero TokenExpiresAt before Init, got %v", got)
+	}
+	if err := session.Init(context.Background()); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	if got := session.TokenExpiresAt(); !got.Equal(expireAt) {
+		t.Fatalf("expected TokenExpiresAt %v after Init, got %v", expireAt, got)
+	}
+
+	waitForCount(t, issued, 3)
+	if got := session.TokenExpiresAt(); !got.After(expireAt) {
+		t.Fatalf("expected refreshed TokenExpiresAt after %v, got %v", expireAt, got)
+	}
+
+	if _, err := session.Start(context.Background()); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	if token := <-dialTokens; token == "session-token-1" {
+		t.Fatal("expected Start to use a refreshed session token")
+	}
+
+	if err := session.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	stoppedAt := issued.Load()
+	time.Sleep(400 * time.Millisecond)
+	if got := issued.Load(); got != stoppedAt {
+		t.Fatalf("expected refresher to stop after Close, got %d more refreshes", got-stoppedAt)
+	}
+}
+
+func TestTokenRefreshReportsErrors(t *testing.T) {
+	console, issued := newTokenConsole(t, func(n int32) int {
+		if n == 1 {
+			return http.StatusOK
+		}
+		return http.StatusInternalServerError
+	})
+
+	errs := make(chan error, 16)
+	session := NewAvatarSession(
+		WithAPIKey("api-key"),
+		WithExpireAt(time.Now().Add(100*time.Millisecond)),
+		WithConsoleEndpointURL(console.URL),
+		WithTokenRefresh(TokenRefreshPolicy{RetryInterval: 10 * time.Millisecond}),
+		WithOnError(func(err error) { errs <- err }),
+	)
+	defer session.Close() // nolint:errcheck
+
+	if err := session.Init(context.Background()); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "refresh session token: request failed with status 500") {
+			t.Fatalf("unexpected refresh error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for refresh error")
+	}
+
+	// Failed refreshes are retried and keep the previous token.
+	waitForCount(t, issued, 3)
+	session.mu.Lock()
+	token := session.sessionToken
+	session.mu.Unlock()
+	if token != "session-token-1" {
+		t.Fatalf("expected previous session token to be kept, got %q", token)
+	}
+}
+
+func TestTokenRefreshPolicyDefaults(t *testing.T) {
+	policy := TokenRefreshPolicy{}.withDefaults(time.Hour)
+	if policy.TTL != time.Hour {
+		t.Fatalf("expected TTL to default to the initial lifetime, got %v", policy.TTL)
+	}
+	if policy.Lead != defaultTokenRefreshLead {
+		t.Fatalf("expected default lead, got %v", policy.Lead)
+	}
+	if policy.RetryInterval != defaultTokenRefreshRetryInterval {
+		t.Fatalf("expected default retry interval, got %v", policy.RetryInterval)
+	}
+
+	policy = TokenRefreshPolicy{Lead: time.Hour, TTL: time.Minute}.withDefaults(0)
+	if policy.Lead != 30*time.Second {
+		t.Fatalf("expected lead to be capped at half the TTL, got %v", policy.Lead)
+	}
+
+	policy = TokenRefreshPolicy{}.withDefaults(-time.Second)
+	if policy.TTL <= 0 || policy.Lead >= policy.TTL {
+		t.Fatalf("expected a usable policy for an expired initial token, got %+v", policy)
+	}
+}
@@ -0,0 +1,211 @@
+package avatarsdkgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultConsoleTokenTTL is the token lifetime requested by ConsoleTokenSource when TTL is unset.
+const defaultConsoleTokenTTL = time.Hour
+
+// ErrSessionTokenNotRenewable is reported when the ingress rejected the session token and the
+// TokenSource cannot supply a different one, as with StaticTokenSource. The session then stops
+// reconnecting instead of redialing with the rejected token.
+var ErrSessionTokenNotRenewable = errors.New("session token rejected and cannot be renewed")
+
+// errRejectedTokenReturned reports a TokenSource that handed back the token the ingress rejected.
+var errRejectedTokenReturned = fmt.Errorf("token source returned the rejected token: %w", ErrSessionTokenNotRenewable)
+
+// TokenSource supplies session tokens for the ingress websocket. It lets tokens be minted by a
+// trusted backend that holds the API key while sessions run elsewhere.
+//
+// Token returns a session token and its expiry. A zero expiresAt means the expiry is unknown;
+// such tokens are not refreshed by WithTokenRefresh. Implementations must be safe for
+// concurrent use.
+type TokenSource interface {
+	Token(ctx context.Context) (token string, expiresAt time.Time, err error)
+}
+
+// TokenInvalidator is implemented by a TokenSource that can drop a token the ingress rejected.
+// When a reconnect is refused because the session token expired, the session calls Invalidate
+// before asking the source for a replacement. CachingTokenSource implements it.
+type TokenInvalidator interface {
+	Invalidate()
+}
+
+// ConsoleTokenSource mints session tokens against the console API with an API key.
+// It is the TokenSource equivalent of Init with WithAPIKey.
+type ConsoleTokenSource struct {
+	// EndpointURL is the console API base URL.
+	EndpointURL string
+	// APIKey authenticates the token requests.
+	APIKey string
+	// ModelVersion is the model version requested with each token. Empty uses the console default.
+	ModelVersion string
+	// TTL is the lifetime requested for each token. Defaults to one hour.
+	TTL time.Duration
+	// HTTPClient sends the token requests. Nil uses http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Token implements TokenSource by minting a new token on every call.
+func (c ConsoleTokenSource) Token(ctx context.Context) (string, time.Time, error) {
+	if c.APIKey == "" {
+		return "", time.Time{}, errors.New("console token source: missing API key")
+	}
+	if c.EndpointURL == "" {
+		return "", time.Time{}, errors.New("console token source: missing console endpoint URL")
+	}
+	ttl := c.TTL
+	if ttl <= 0 {
+		ttl = defaultConsoleTokenTTL
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	token, err := mintSessionToken(ctx, client, c.EndpointURL, c.APIKey, c.ModelVersion, expiresAt)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("console token source: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+// staticTokenSource always returns the same pre-minted token.
+type staticTokenSource struct {
+	token     string
+	expiresAt time.Time
+}
+
+// StaticTokenSource returns a TokenSource for a token minted elsewhere. expiresAt may be zero
+// when the expiry is unknown. A session using it cannot renew the token; if the ingress rejects
+// it on reconnect the session closes with ErrSessionTokenNotRenewable.
+func StaticTokenSource(token string, expiresAt time.Time) TokenSource {
+	return staticTokenSource{token: token, expiresAt: expiresAt}
+}
+
+// Token implements TokenSource.
+func (s staticTokenSource) Token(context.Context) (string, time.Time, error) {
+	if s.token == "" {
+		return "", time.Time{}, errors.New("static token source: empty session token")
+	}
+	return s.token, s.expiresAt, nil
+}
+
+// CachingTokenSource shares the token of another TokenSource, for example across the sessions
+// of a connection pool. The cached token is reused until it is within Lead of its expiry; the
+// next call then fetches a new one. Concurrent callers wait for a single fetch. Errors are
+// not cached.
+type CachingTokenSource struct {
+	source TokenSource
+	lead   time.Duration
+
+	// fetch is a one-slot semaphore serializing fetches so waiters can give up when their
+	// context ends.
+	fetch chan struct{}
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+
+	now func() time.Time
+}
+
+// NewCachingTokenSource wraps source with a cache that refetches lead before the cached
+// token expires. A lead of zero or less uses 30 seconds.
+func NewCachingTokenSource(source TokenSource, lead time.Duration) *CachingTokenSource {
+	if lead <= 0 {
+		lead = defaultTokenRefreshLead
+	}
+	return &CachingTokenSource{
+		source: source,
+		lead:   lead,
+		fetch:  make(chan struct{}, 1),
+		now:    time.Now,
+	}
+}
+
+// Token implements TokenSource.
+func (c *CachingTokenSource) Token(ctx context.Context) (string, time.Time, error) {
+	if token, expiresAt, ok := c.cached(); ok {
+		return token, expiresAt, nil
+	}
+
+	select {
+	case c.fetch <- struct{}{}:
+	case <-ctx.Done():
+		return "", time.Time{}, ctx.Err()
+	}
+	defer func() { <-c.fetch }()
+
+	// Another caller may have fetched while this one waited.
+	if token, expiresAt, ok := c.cached(); ok {
+		return token, expiresAt, nil
+	}
+
+	token, expiresAt, err := c.source.Token(ctx)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	c.mu.Lock()
+	c.token = token
+	c.expiresAt = expiresAt
+	c.mu.Unlock()
+	return token, expiresAt, nil
+}
+
+// Invalidate implements TokenInvalidator. It drops the cached token so the next call fetches a
+// new one.
+func (c *CachingTokenSource) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = ""
+	c.expiresAt = time.Time{}
+}
+
+// cached returns the cached token if it is still outside the refresh lead.
+func (c *CachingTokenSource) cached() (string, time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == "" {
+		return "", time.Time{}, false
+	}
+	if !c.expiresAt.IsZero() && !c.now().Before(c.expiresAt.Add(-c.lead)) {
+		return "", time.Time{}, false
+	}
+	return c.token, c.expiresAt, true
+}
+
+// invalidateSourceToken tells the configured TokenSource that the ingress rejected its token,
+// if the source implements TokenInvalidator.
+func (s *AvatarSession) invalidateSourceToken() {
+	if invalidator, ok := s.config.TokenSource.(TokenInvalidator); ok {
+		s.invokeCallback("TokenSource.Invalidate", invalidator.Invalidate)
+	}
+}
+
+// tokenFromSource fetches a session token from the configured TokenSource.
+func (s *AvatarSession) tokenFromSource(ctx context.Context) (_ string, _ time.Time, err error) {
+	ctx, span := s.startSpan(ctx, SpanTokenExchange)
+	defer func() { endSpan(span, err) }()
+
+	var token string
+	var expiresAt time.Time
+	err = errCallbackPanicked
+	s.invokeCallback("TokenSource.Token", func() {
+		token, expiresAt, err = s.config.TokenSource.Token(ctx)
+	})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("token source: %w", err)
+	}
+	if token == "" {
+		return "", time.Time{}, errors.New("token source: empty session token")
+	}
+	return token, expiresAt, nil
+}
@@ -0,0 +1,229 @@
+package avatarsdkgo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConsoleTokenSourceMintsTokens(t *testing.T) {
+	var payload sessionTokenRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if apiKey := r.Header.Get("X-Api-Key"); apiKey != "api-key" {
+			t.Errorf("expected X-Api-Key header to be api-key, got %q", apiKey)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("failed to decode request payload: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(sessionTokenResponse{SessionToken: "session-token-123"})
+	}))
+	defer server.Close()
+
+	source := ConsoleTokenSource{
+		EndpointURL:  server.URL,
+		APIKey:       "api-key",
+		ModelVersion: "v2.1",
+		TTL:          10 * time.Minute,
+	}
+	before := time.Now()
+	token, expiresAt, err := source.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Token returned error: %v", err)
+	}
+	if token != "session-token-123" {
+		t.Fatalf("expected session-token-123, got %q", token)
+	}
+	if expiresAt.Before(before.Add(10*time.Minute)) || expiresAt.After(time.Now().Add(10*time.Minute)) {
+		t.Fatalf("expected expiry 10 minutes from now, got %v", expiresAt)
+	}
+	if payload.ExpireAt != expiresAt.Unix() || payload.ModelVersion != "v2.1" {
+		t.Fatalf("unexpected token request %+v", payload)
+	}
+
+	if _, _, err := (ConsoleTokenSource{EndpointURL: server.URL}).Token(context.Background()); err == nil || !strings.Contains(err.Error(), "missing API key") {
+		t.Fatalf("expected missing API key error, got %v", err)
+	}
+}
+
+func TestWithSessionTokenSkipsConsole(t *testing.T) {
+	dialTokens := make(chan string, 1)
+	ingress := newFakeIngressWithAuth(t, func(r *http.Request) int {
+		dialTokens <- r.Header.Get("X-Session-Key")
+		return http.StatusOK
+	})
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	session := NewAvatarSession(
+		WithAvatarID("avatar-123"),
+		WithAppID("app-123"),
+		WithIngressEndpointURL(ingress.url()),
+		WithSessionToken("pre-minted-token", expiresAt),
+	)
+	if err := session.Init(context.Background()); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	if !session.TokenExpiresAt().Equal(expiresAt) {
+		t.Fatalf("expected TokenExpiresAt %v, got %v", expiresAt, session.TokenExpiresAt())
+	}
+	if _, err := session.Start(context.Background()); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	defer session.Close() // nolint:errcheck
+	ingress.accept(t)
+
+	if got := <-dialTokens; got != "pre-minted-token" {
+		t.Fatalf("expected the pre-minted token on dial, got %q", got)
+	}
+}
+
+func TestTokenSourceErrorFailsInit(t *testing.T) {
+	sourceErr := errors.New("backend unavailable")
+	session := NewAvatarSession(WithTokenSource(tokenSourceFunc(func(context.Context) (string, time.Time, error) {
+		return "", time.Time{}, sourceErr
+	})))
+	if err := session.Init(context.Background()); !errors.Is(err, sourceErr) {
+		t.Fatalf("expected the token source error, got %v", err)
+	}
+	if state := session.State(); state != SessionStateIdle {
+		t.Fatalf("expected session to stay idle, got %s", state)
+	}
+}
+
+func TestTokenRefreshUsesTokenSource(t *testing.T) {
+	var calls atomic.Int32
+	source := tokenSourceFunc(func(context.Context) (string, time.Time, error) {
+		n := calls.Add(1)
+		return fmt.Sprintf("source-token-%d", n), time.Now().Add(300 * time.Millisecond), nil
+	})
+	session := NewAvatarSession(
+		WithTokenSource(source),
+		WithTokenRefresh(TokenRefreshPolicy{Lead: 100 * time.Millisecond}),
+	)
+	if err := session.Init(context.Background()); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	defer session.Close() // nolint:errcheck
+
+	waitForCount(t, &calls, 2)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		session.mu.Lock()
+		token := session.sessionToken
+		session.mu.Unlock()
+		if token != "source-token-1" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the refreshed token")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestCachingTokenSourceSharesToken(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, time.October, 27, 14, 30, 34, 0, time.UTC)}
+	var calls atomic.Int32
+	release := make(chan struct{})
+	source := tokenSourceFunc(func(context.Context) (string, time.Time, error) {
+		n := calls.Add(1)
+		<-release
+		return fmt.Sprintf("token-%d", n), clock.Now().Add(time.Minute), nil
+	})
+	cache := NewCachingTokenSource(source, 10*time.Second)
+	cache.now = clock.Now
+
+	// Concurrent callers share a single fetch.
+	var wg sync.WaitGroup
+	tokens := make(chan string, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, _, err := cache.Token(context.Background())
+			if err != nil {
+				t.Errorf("Token returned error: %v", err)
+			}
+			tokens <- token
+		}()
+	}
+	waitForCount(t, &calls, 1)
+	close(release)
+	wg.Wait()
+	close(tokens)
+	for token := range tokens {
+		if token != "token-1" {
+			t.Fatalf("expected every caller to get token-1, got %q", token)
+		}
+	}
+
+	// The token is reused until it is within the lead of its expiry.
+	clock.advance(49 * time.Second)
+	if token, _, _ := cache.Token(context.Background()); token != "token-1" {
+		t.Fatalf("expected the cached token before the lead, got %q", token)
+	}
+	clock.advance(time.Second)
+	if token, _, _ := cache.Token(context.Background()); token != "token-2" {
+		t.Fatalf("expected a new token within the lead, got %q", token)
+	}
+
+	cache.Invalidate()
+	if token, _, _ := cache.Token(context.Background()); token != "token-3" {
+		t.Fatalf("expected a new token after Invalidate, got %q", token)
+	}
+}
+
+func TestCachingTokenSourceDoesNotCacheErrors(t *testing.T) {
+	var calls atomic.Int32
+	source := tokenSourceFunc(func(context.Context) (string, time.Time, error) {
+		if calls.Add(1) == 1 {
+			return "", time.Time{}, errors.New("backend unavailable")
+		}
+		return "token", time.Time{}, nil
+	})
+	cache := NewCachingTokenSource(source, 0)
+
+	if _, _, err := cache.Token(context.Background()); err == nil {
+		t.Fatal("expected the first fetch to fail")
+	}
+	for i := 0; i < 3; i++ {
+		if token, _, err := cache.Token(context.Background()); err != nil || token != "token" {
+			t.Fatalf("expected the cached token, got %q, %v", token, err)
+		}
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected a token without expiry to be cached after one retry, got %d fetches", calls.Load())
+	}
+}
+
+func TestCachingTokenSourceWaitHonorsContext(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	cache := NewCachingTokenSource(tokenSourceFunc(func(context.Context) (string, time.Time, error) {
+		<-release
+		return "token", time.Time{}, nil
+	}), 0)
+
+	go cache.Token(context.Background()) // nolint:errcheck
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := cache.Token(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the waiting caller to give up, got %v", err)
+	}
+}
+
+type tokenSourceFunc func(context.Context) (string, time.Time, error)
+
+func (f tokenSourceFunc) Token(ctx context.Context) (string, time.Time, error) {
+	return f(ctx)
+}
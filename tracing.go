@@ -0,0 +1,131 @@
+package avatarsdkgo
+
+import (
+	"context"
+	"errors"
+)
+
+// Span names reported to a Tracer.
+const (
+	// SpanTokenExchange covers a session token request against the console API, from Init or a refresh.
+	SpanTokenExchange = "avatar.token_exchange"
+	// SpanWebSocketDial covers dialing the ingress websocket, including the HTTP upgrade.
+	SpanWebSocketDial = "avatar.websocket_dial"
+	// SpanHandshake covers sending ClientConfigureSession and waiting for ServerConfirmSession.
+	SpanHandshake = "avatar.handshake"
+	// SpanRequest covers a request from its first audio chunk to its final frame.
+	SpanRequest = "avatar.request"
+)
+
+// Span attribute keys reported to a Tracer.
+const (
+	AttrAvatarID        = "avatar.avatar_id"
+	AttrConnectionID    = "avatar.connection_id"
+	AttrReqID           = "avatar.req_id"
+	AttrErrorCode       = "avatar.error_code"
+	AttrServerErrorCode = "avatar.server_error_code"
+)
+
+// SpanAttribute is a key/value pair attached to a span.
+type SpanAttribute struct {
+	Key   string
+	Value string
+}
+
+// Tracer receives spans for the session's network operations. It has no dependencies so it can
+// be bridged to OpenTelemetry or any other tracing system.
+//
+// StartSpan is called when an operation begins. The context passed in is the one given to the
+// SDK call that started the operation, or a context owned by the session for operations it
+// runs on its own, such as reconnects and token refreshes. The returned context is used as the
+// parent of nested spans. Tracer methods are called synchronously and must not block.
+type Tracer interface {
+	StartSpan(ctx context.Context, name string, attrs ...SpanAttribute) (context.Context, Span)
+}
+
+// Span is an operation started by a Tracer.
+type Span interface {
+	// SetAttributes adds attributes learned while the operation runs, such as the connection ID.
+	SetAttributes(attrs ...SpanAttribute)
+	// End is called exactly once when the operation completes. err is nil on success. When
+	// err is an *AvatarSDKError its codes have been added as attributes beforehand.
+	End(err error)
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...SpanAttribute) {}
+func (noopSpan) End(error)                      {}
+
+// startSpan starts a span on the configured tracer, or returns a no-op span without one.
+// Panics in the tracer and its span are recovered like those of other user callbacks; a span
+// that could not be started is replaced by a no-op span.
+func (s *AvatarSession) startSpan(ctx context.Context, name string, attrs ...SpanAttribute) (context.Context, Span) {
+	tracer := s.config.Tracer
+	if tracer == nil {
+		return ctx, noopSpan{}
+	}
+	attrs = append([]SpanAttribute{{Key: AttrAvatarID, Value: s.config.AvatarID}}, attrs...)
+
+	spanCtx, span := ctx, Span(noopSpan{})
+	s.invokeCallback("Tracer.StartSpan", func() {
+		spanCtx, span = tracer.StartSpan(ctx, name, attrs...)
+	})
+	if spanCtx == nil || span == nil {
+		return ctx, noopSpan{}
+	}
+	return spanCtx, recoveringSpan{session: s, span: span}
+}
+
+// recoveringSpan recovers from panics in the Span returned by a Tracer.
+type recoveringSpan struct {
+	session *AvatarSession
+	span    Span
+}
+
+func (r recoveringSpan) SetAttributes(attrs ...SpanAttribute) {
+	r.session.invokeCallback("Span.SetAttributes", func() { r.span.SetAttributes(attrs...) })
+}
+
+func (r recoveringSpan) End(err error) {
+	r.session.invokeCallback("Span.End", func() { r.span.End(err) })
+}
+
+// endSpan records the error codes carried by err on span and ends it.
+func endSpan(span Span, err error) {
+	var sdkErr *AvatarSDKError
+	if errors.As(err, &sdkErr) {
+		attrs := []SpanAttribute{{Key: AttrErrorCode, Value: string(sdkErr.Code)}}
+		if sdkErr.ServerCode != "" {
+			attrs = append(attrs, SpanAttribute{Key: AttrServerErrorCode, Value: sdkErr.ServerCode})
+		}
+		if sdkErr.ConnectionID != "" {
+			attrs = append(attrs, SpanAttribute{Key: AttrConnectionID, Value: sdkErr.ConnectionID})
+		}
+		span.SetAttributes(attrs...)
+	}
+	span.End(err)
+}
+
+// traceRequest starts req's span when its first audio chunk is sent.
+// Callers must hold the send lock and must not hold s.mu.
+func (s *AvatarSession) traceRequest(ctx context.Context, req *Request, connectionID string) {
+	if req.traced || s.config.Tracer == nil {
+		return
+	}
+	req.traced = true
+	_, span := s.startSpan(ctx, SpanRequest,
+		SpanAttribute{Key: AttrReqID, Value: req.id},
+		SpanAttribute{Key: AttrConnectionID, Value: connectionID},
+	)
+
+	req.mu.Lock()
+	finished, err := req.finished, req.err
+	if !finished {
+		req.span = span
+	}
+	req.mu.Unlock()
+	if finished {
+		endSpan(span, err)
+	}
+}
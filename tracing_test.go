@@ -0,0 +1,229 @@
+package avatarsdkgo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	message "github.com/spatialwalk/avatar-sdk-go/proto/generated"
+)
+
+func TestTracerSpansInitAndStart(t *testing.T) {
+	console, _ := newTokenConsole(t, func(int32) int { return http.StatusOK })
+	ingress := newFakeIngress(t)
+	tracer := &recordingTracer{}
+
+	session := NewAvatarSession(
+		WithAPIKey("api-key"),
+		WithExpireAt(time.Now().Add(time.Hour)),
+		WithConsoleEndpointURL(console.URL),
+		WithAvatarID("avatar-123"),
+		WithAppID("app-123"),
+		WithIngressEndpointURL(ingress.url()),
+		WithTracer(tracer),
+	)
+	ctx := context.WithValue(context.Background(), traceParentKey{}, "turn-1")
+	if err := session.Init(ctx); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	connectionID, err := session.Start(ctx)
+	if err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	defer session.Close() // nolint:errcheck
+	ingress.accept(t)
+
+	spans := tracer.finished()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+	for i, name := range []string{SpanTokenExchange, SpanWebSocketDial, SpanHandshake} {
+		span := spans[i]
+		if span.name != name {
+			t.Fatalf("expected span %d to be %q, got %q", i, name, span.name)
+		}
+		if span.err != nil {
+			t.Fatalf("expected %s to succeed, got %v", name, span.err)
+		}
+		if span.parent != "turn-1" {
+			t.Fatalf("expected %s to inherit the caller context, got parent %q", name, span.parent)
+		}
+		if span.attrs[AttrAvatarID] != "avatar-123" {
+			t.Fatalf("expected avatar ID attribute on %s, got %v", name, span.attrs)
+		}
+	}
+	if got := spans[2].attrs[AttrConnectionID]; got != connectionID {
+		t.Fatalf("expected handshake connection ID %q, got %q", connectionID, got)
+	}
+}
+
+func TestTracerSpansRequests(t *testing.T) {
+	ingress := newFakeIngress(t)
+	tracer := &recordingTracer{}
+	session := startTestSession(t, ingress, WithTracer(tracer))
+	defer session.Close() // nolint:errcheck
+	serverConn := ingress.accept(t)
+	tracer.reset()
+
+	ok, err := session.BeginRequest()
+	if err != nil {
+		t.Fatalf("BeginRequest returned error: %v", err)
+	}
+	if len(tracer.finished()) != 0 || tracer.started() != 0 {
+		t.Fatal("expected no request span before the first audio chunk")
+	}
+	if err := ok.Write([]byte{0x01, 0x02}); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	if err := ok.End(); err != nil {
+		t.Fatalf("End returned error: %v", err)
+	}
+	failed, err := session.BeginRequest()
+	if err != nil {
+		t.Fatalf("BeginRequest returned error: %v", err)
+	}
+	if err := failed.Write([]byte{0x01, 0x02}); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	if tracer.started() != 2 {
+		t.Fatalf("expected one span per request, got %d", tracer.started())
+	}
+
+	serverConn.send(t, animationMessage(serverConn.connectionID, ok.ID(), true))
+	serverConn.send(t, &message.Message{
+		Type: message.MessageType_MESSAGE_SERVER_ERROR,
+		Data: &message.Message_ServerError{
+			ServerError: &message.ServerError{
+				ConnectionId: serverConn.connectionID,
+				ReqId:        failed.ID(),
+				Code:         400,
+				Message:      "bad audio",
+			},
+		},
+	})
+	for _, req := range []*Request{ok, failed} {
+		_ = req.Wait(context.Background())
+	}
+
+	spans := tracer.finished()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 finished spans, got %d", len(spans))
+	}
+	for _, span := range spans {
+		if span.name != SpanRequest {
+			t.Fatalf("expected request span, got %q", span.name)
+		}
+		if span.attrs[AttrConnectionID] != serverConn.connectionID {
+			t.Fatalf("expected connection ID attribute, got %v", span.attrs)
+		}
+		switch span.attrs[AttrReqID] {
+		case ok.ID():
+			if span.err != nil {
+				t.Fatalf("expected successful request span, got %v", span.err)
+			}
+		case failed.ID():
+			if span.err == nil || span.attrs[AttrServerErrorCode] != "400" {
+				t.Fatalf("expected server error code 400 on failed span, got err=%v attrs=%v", span.err, span.attrs)
+			}
+		default:
+			t.Fatalf("unexpected request span attributes %v", span.attrs)
+		}
+	}
+}
+
+func TestTracerRecordsDialErrorCode(t *testing.T) {
+	ingress := newFakeIngressWithAuth(t, func(*http.Request) int { return http.StatusUnauthorized })
+	tracer := &recordingTracer{}
+	session := NewAvatarSession(
+		WithAvatarID("avatar-123"),
+		WithAppID("app-123"),
+		WithIngressEndpointURL(ingress.url()),
+		WithTracer(tracer),
+	)
+	session.sessionToken = "session-token-123"
+
+	if _, err := session.Start(context.Background()); err == nil {
+		t.Fatal("expected Start to fail")
+	}
+	spans := tracer.finished()
+	if len(spans) != 1 || spans[0].name != SpanWebSocketDial {
+		t.Fatalf("expected a single dial span, got %+v", spans)
+	}
+	var sdkErr *AvatarSDKError
+	if !errors.As(spans[0].err, &sdkErr) {
+		t.Fatalf("expected AvatarSDKError on dial span, got %v", spans[0].err)
+	}
+	if got := spans[0].attrs[AttrErrorCode]; got != string(ErrorCodeSessionTokenExpired) {
+		t.Fatalf("expected error code %q, got %q", ErrorCodeSessionTokenExpired, got)
+	}
+}
+
+type traceParentKey struct{}
+
+type recordedSpan struct {
+	tracer *recordingTracer
+	name   string
+	parent string
+	attrs  map[string]string
+	err    error
+}
+
+func (s *recordedSpan) SetAttributes(attrs ...SpanAttribute) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *recordedSpan) End(err error) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.err = err
+	s.tracer.ended = append(s.tracer.ended, s)
+}
+
+type recordingTracer struct {
+	mu     sync.Mutex
+	starts int
+	ended  []*recordedSpan
+}
+
+func (t *recordingTracer) StartSpan(ctx context.Context, name string, attrs ...SpanAttribute) (context.Context, Span) {
+	parent, _ := ctx.Value(traceParentKey{}).(string)
+	span := &recordedSpan{tracer: t, name: name, parent: parent, attrs: make(map[string]string)}
+	for _, attr := range attrs {
+		span.attrs[attr.Key] = attr.Value
+	}
+	t.mu.Lock()
+	t.starts++
+	t.mu.Unlock()
+	return ctx, span
+}
+
+func (t *recordingTracer) started() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.starts
+}
+
+// finished returns copies of the ended spans in the order they ended.
+func (t *recordingTracer) finished() []recordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	spans := make([]recordedSpan, len(t.ended))
+	for i, span := range t.ended {
+		spans[i] = *span
+	}
+	return spans
+}
+
+func (t *recordingTracer) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.starts = 0
+	t.ended = nil
+}